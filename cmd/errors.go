@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/boozedog/webmd/internal/browser"
+	"github.com/boozedog/webmd/internal/convert"
+	"github.com/boozedog/webmd/internal/fetch"
+)
+
+// Exit codes returned by the CLI. Scripts can rely on these staying stable.
+const (
+	ExitOK         = 0
+	ExitError      = 1 // invalid usage or any other failure
+	ExitBrowser    = 3 // browser could not be launched or connected to
+	ExitNavigation = 4 // page failed to load
+	ExitTimeout    = 5 // page timed out; partial content may have been written
+	ExitStatus     = 6 // upstream status matched --fail-on-status
+	ExitConversion = 7 // HTML or markdown conversion failed
+	ExitSection    = 8 // no heading matched --section
+)
+
+// errorInfo is the structured form of an error, printed by --json-errors.
+type errorInfo struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	ExitCode int    `json:"exit_code"`
+	URL      string `json:"url,omitempty"`
+	Status   int    `json:"status,omitempty"`
+}
+
+// classify maps err to a stable error code and exit code.
+func classify(err error) errorInfo {
+	info := errorInfo{Code: "error", Message: err.Error(), ExitCode: ExitError}
+
+	var launchErr *browser.LaunchError
+	var navErr *fetch.NavigationError
+	var timeoutErr *fetch.TimeoutError
+	var statusErr *fetch.StatusError
+	var convErr *convert.ConversionError
+	var sectionErr *convert.SectionNotFoundError
+	var tooLargeErr *fetch.TooLargeError
+	switch {
+	case errors.As(err, &launchErr):
+		info.Code, info.ExitCode = "browser_launch", ExitBrowser
+	case errors.As(err, &navErr):
+		info.Code, info.ExitCode, info.URL = "navigation", ExitNavigation, navErr.URL
+	case errors.As(err, &timeoutErr):
+		info.Code, info.ExitCode, info.URL = "timeout", ExitTimeout, timeoutErr.URL
+	case errors.As(err, &tooLargeErr):
+		info.Code, info.ExitCode, info.URL = "too_large", ExitNavigation, tooLargeErr.URL
+	case errors.As(err, &statusErr):
+		info.Code, info.ExitCode, info.URL, info.Status = "upstream_status", ExitStatus, statusErr.URL, statusErr.Status
+	case errors.As(err, &convErr):
+		info.Code, info.ExitCode = "conversion", ExitConversion
+	case errors.As(err, &sectionErr):
+		info.Code, info.ExitCode = "section_not_found", ExitSection
+	}
+	return info
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return classify(err).ExitCode
+}
+
+// printError writes err to w, as a JSON object when jsonErrors is set.
+func printError(w io.Writer, err error, jsonErrors bool) {
+	if !jsonErrors {
+		fmt.Fprintln(w, "Error:", err)
+		return
+	}
+	b, _ := json.Marshal(classify(err))
+	fmt.Fprintln(w, string(b))
+}
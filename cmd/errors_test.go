@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boozedog/webmd/internal/browser"
+	"github.com/boozedog/webmd/internal/convert"
+	"github.com/boozedog/webmd/internal/fetch"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"generic", errors.New("boom"), ExitError},
+		{"launch", &browser.LaunchError{Err: errors.New("no chrome")}, ExitBrowser},
+		{"navigation", &fetch.NavigationError{URL: "https://example.com", Err: errors.New("net::ERR")}, ExitNavigation},
+		{"timeout", &fetch.TimeoutError{URL: "https://example.com", Partial: true}, ExitTimeout},
+		{"status", &fetch.StatusError{URL: "https://example.com", Status: 404}, ExitStatus},
+		{"conversion", &convert.ConversionError{Err: errors.New("bad")}, ExitConversion},
+		{"section", &convert.SectionNotFoundError{Query: "Install"}, ExitSection},
+		{"too large", &fetch.TooLargeError{URL: "https://example.com/big.pdf", Limit: fetch.MaxBodySize}, ExitNavigation},
+		{"wrapped", fmt.Errorf("reconnecting: %w", &browser.LaunchError{Err: errors.New("refused")}), ExitBrowser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintErrorJSON(t *testing.T) {
+	var b strings.Builder
+	printError(&b, &fetch.StatusError{URL: "https://example.com", Status: 404}, true)
+	want := `{"code":"upstream_status","message":"https://example.com returned HTTP 404","exit_code":6,"url":"https://example.com","status":404}` + "\n"
+	if b.String() != want {
+		t.Errorf("printError() = %q, want %q", b.String(), want)
+	}
+}
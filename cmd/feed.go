@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boozedog/webmd/internal/convert"
+	"github.com/boozedog/webmd/internal/feed"
+)
+
+// defaultMaxEntries is how many entry pages are fetched unless overridden.
+const defaultMaxEntries = 10
+
+// digest renders a feed as markdown: one section per entry with its title,
+// date, link, and summary. With fetchEntries, the pages of the first
+// maxEntries entries are converted through the readability path in place of
+// their summaries.
+func (p *pipeline) digest(f *feed.Feed) string {
+	entries := f.Entries
+	if !p.since.IsZero() {
+		entries = f.Since(p.since)
+	}
+
+	var b strings.Builder
+	title := f.Title
+	if title == "" {
+		title = p.fetch.URL
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if f.Link != "" {
+		fmt.Fprintf(&b, "<%s>\n\n", f.Link)
+	}
+	if len(entries) == 0 {
+		b.WriteString("No entries.\n")
+		return b.String()
+	}
+
+	fetched := 0
+	for _, e := range entries {
+		title := e.Title
+		if title == "" {
+			title = e.Link
+		}
+		if e.Link != "" {
+			fmt.Fprintf(&b, "## [%s](%s)\n\n", linkTextEscaper.Replace(title), e.Link)
+		} else {
+			fmt.Fprintf(&b, "## %s\n\n", title)
+		}
+		if !e.Published.IsZero() {
+			fmt.Fprintf(&b, "*%s*\n\n", e.Published.UTC().Format("2006-01-02 15:04 UTC"))
+		}
+
+		if p.fetchEntries && e.Link != "" && fetched < p.maxEntries {
+			var md string
+			err := checkEntryLink(e.Link)
+			if err == nil {
+				fetched++
+				md, err = p.entry(e.Link)
+			}
+			if err == nil {
+				b.WriteString(strings.TrimSpace(md) + "\n\n")
+				continue
+			}
+			fmt.Fprintf(&b, "*[webmd: could not fetch entry: %s]*\n\n", err)
+		}
+		if summary := p.summary(e.Summary); summary != "" {
+			b.WriteString(summary + "\n\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+var linkTextEscaper = strings.NewReplacer("[", `\[`, "]", `\]`)
+
+// entry converts a feed entry's page with readability, nesting its headings
+// under the entry heading.
+func (p *pipeline) entry(link string) (string, error) {
+	sub := *p
+	sub.fetch.URL = link
+	sub.article = true
+	sub.fetchEntries = false
+	sub.failOnStatus = nil
+	sub.fetch.Screenshot = nil
+	sub.pageMeta = false
+	doc, err := sub.convertURL()
+	if err != nil {
+		return "", err
+	}
+	return convert.DemoteHeadings(doc.markdown, 2), nil
+}
+
+// checkEntryLink rejects entry links that aren't http(s). Feeds are untrusted,
+// so they must not send the browser to local files or other schemes.
+func checkEntryLink(link string) error {
+	if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("not an http(s) URL: %s", link)
+	}
+	return nil
+}
+
+// summary converts an entry summary, which is usually HTML, to markdown.
+func (p *pipeline) summary(s string) string {
+	if s == "" {
+		return ""
+	}
+	html := convert.StripHidden(s)
+	if !p.images {
+		html = convert.StripImages(html)
+	}
+	md, err := convert.Full(html)
+	if err != nil {
+		return s
+	}
+	return strings.TrimSpace(convert.DemoteHeadings(convert.StripJunkLinks(md), 2))
+}
+
+// parseSince parses the since option; an empty value disables filtering.
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return feed.ParseSince(s, time.Now())
+}
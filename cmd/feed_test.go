@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boozedog/webmd/internal/feed"
+	"github.com/boozedog/webmd/internal/fetch"
+)
+
+func TestDigestFetchEntries(t *testing.T) {
+	// HTML responses need the browser, so Direct hands them to page.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer srv.Close()
+
+	var fetched []string
+	p := &pipeline{
+		fetchEntries: true,
+		maxEntries:   1,
+		page: func(opts fetch.Options) (*fetch.Result, error) {
+			fetched = append(fetched, opts.URL)
+			return &fetch.Result{HTML: "<p>Full text of " + opts.URL + "</p>"}, nil
+		},
+	}
+	f := &feed.Feed{Title: "Blog", Entries: []feed.Entry{
+		{Title: "Local", Link: "file:///etc/passwd", Summary: "Local summary"},
+		{Title: "First", Link: srv.URL + "/first", Summary: "First summary"},
+		{Title: "Second", Link: srv.URL + "/second", Summary: "Second summary"},
+	}}
+	md := p.digest(f)
+
+	if len(fetched) != 1 || fetched[0] != srv.URL+"/first" {
+		t.Errorf("fetched %v, want only the first http entry", fetched)
+	}
+	for _, want := range []string{"Local summary", "not an http(s) URL", "Full text of " + srv.URL + "/first", "Second summary"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("digest missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boozedog/webmd/internal/convert"
+	"github.com/boozedog/webmd/internal/fetch"
+)
+
+// localInput is an HTML document read from a file or stdin rather than fetched.
+type localInput struct {
+	html string
+	url  string // file:// URL of the document; empty for stdin
+}
+
+// isLocalInput reports whether a command-line argument names local input:
+// "-" for stdin or a file:// URL.
+func isLocalInput(arg string) bool {
+	return arg == "-" || strings.HasPrefix(arg, "file://")
+}
+
+// readInput reads local HTML from a file path, a file:// URL, or "-" for stdin.
+func readInput(src string, stdin io.Reader) (*localInput, error) {
+	if src == "-" {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		return &localInput{html: string(b)}, nil
+	}
+
+	path := src
+	if strings.HasPrefix(src, "file://") {
+		u, err := url.Parse(src)
+		if err != nil || (u.Host != "" && u.Host != "localhost") {
+			return nil, fmt.Errorf("invalid file URL %q", src)
+		}
+		path = u.Path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+	return &localInput{html: string(b), url: fileURL(abs)}, nil
+}
+
+func fileURL(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// runLocal converts local HTML, then selects sections and applies the token budget.
+func (p *pipeline) runLocal(in *localInput, render bool) (*document, error) {
+	doc, err := p.convertLocal(in, render)
+	if err != nil {
+		return nil, err
+	}
+	return doc, p.finish(doc)
+}
+
+// convertLocal converts local HTML. By default the HTML is converted as-is;
+// with render it is loaded in the browser first so scripts run. Stdin is
+// written to a temporary file for the browser to load.
+func (p *pipeline) convertLocal(in *localInput, render bool) (*document, error) {
+	start := time.Now()
+	if !render {
+		result := &fetch.Result{HTML: in.html, Response: fetch.Response{FinalURL: in.url}}
+		return p.convertPage(result, "local", start, nil)
+	}
+
+	u := in.url
+	if u == "" {
+		f, err := os.CreateTemp("", "webmd-*.html")
+		if err != nil {
+			return nil, fmt.Errorf("writing stdin to a temporary file: %w", err)
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(in.html)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("writing stdin to a temporary file: %w", err)
+		}
+		u = fileURL(f.Name())
+	}
+
+	opts := p.fetch
+	opts.URL = u
+	fetchStart := time.Now()
+	result, err := p.page(opts)
+	if err != nil {
+		return nil, err
+	}
+	if in.url == "" {
+		result.Response.FinalURL = "" // the temporary file is meaningless to the caller
+	}
+	timing := []convert.TimingStep{{Name: "fetch", Duration: time.Since(fetchStart)}}
+	return p.convertPage(result, "browser", start, timing)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(path, []byte("<p>file</p>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, src := range []string{path, "file://" + path} {
+		in, err := readInput(src, nil)
+		if err != nil {
+			t.Fatalf("readInput(%q) error: %v", src, err)
+		}
+		if in.html != "<p>file</p>" || in.url != "file://"+path {
+			t.Errorf("readInput(%q) = %+v", src, in)
+		}
+	}
+
+	in, err := readInput("-", strings.NewReader("<p>stdin</p>"))
+	if err != nil {
+		t.Fatalf("readInput(-) error: %v", err)
+	}
+	if in.html != "<p>stdin</p>" || in.url != "" {
+		t.Errorf("readInput(-) = %+v", in)
+	}
+
+	if _, err := readInput("file://remote.host/page.html", nil); err == nil {
+		t.Error("readInput should reject file URLs with a remote host")
+	}
+}
@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boozedog/webmd/internal/chunk"
+	"github.com/boozedog/webmd/internal/convert"
+	"github.com/boozedog/webmd/internal/feed"
+	"github.com/boozedog/webmd/internal/fetch"
+	"github.com/boozedog/webmd/internal/tokens"
+)
+
+// pipeline converts a URL to markdown. It is shared by the CLI and the server,
+// which each supply their own way of rendering pages in a browser.
+type pipeline struct {
+	fetch        fetch.Options
+	article      bool
+	images       bool
+	keepNav      bool
+	failOnStatus fetch.StatusMatcher
+
+	// relativeLinks keeps link and image URLs as they appear in the page instead
+	// of resolving them against the page URL. links is the link mode (see
+	// convert.Links); empty means inline.
+	relativeLinks bool
+	links         string
+	dedupLinks    bool
+
+	// Feed options: only entries published since this time are kept (all when
+	// zero), and fetchEntries converts the pages of the first maxEntries
+	// entries instead of their summaries.
+	since        time.Time
+	fetchEntries bool
+	maxEntries   int
+
+	// counter counts the tokens in the converted markdown; nil skips counting.
+	// With maxTokens > 0, the markdown is truncated to fit.
+	counter   tokens.Counter
+	maxTokens int
+
+	// section narrows the markdown to the section whose heading matches (see
+	// convert.ExtractSection); listSections replaces it with the heading outline.
+	section      string
+	listSections bool
+
+	// toc prepends a table of contents; outline replaces the markdown with its
+	// heading tree and the token count of each section.
+	toc     bool
+	outline bool
+
+	// extra holds user-supplied metadata fields (--meta).
+	extra map[string]any
+
+	// structured extracts the page's JSON-LD and microdata, rendered as a
+	// field in JSON output. With appendix, it is also appended to the markdown
+	// (for markdown output), inside the token budget.
+	structured bool
+	appendix   bool
+
+	// pageMeta extracts the metadata a page declares about itself (see
+	// convert.ExtractMeta). It is only worth the work when the metadata is
+	// output, in frontmatter or JSON.
+	pageMeta bool
+
+	// page renders a URL in a browser. It is only called when the server does
+	// not provide markdown directly.
+	page func(fetch.Options) (*fetch.Result, error)
+}
+
+// document is a converted page: the markdown body plus metadata for frontmatter or JSON.
+type document struct {
+	markdown string
+	meta     convert.Metadata
+	timeout  *fetch.TimeoutError // set when the page timed out
+
+	// screenshot is the image of the page captured in the browser, if one was
+	// requested (fetch.Options.Screenshot) and the page was rendered.
+	// screenshotErr is why it is missing when capturing it failed.
+	screenshot    []byte
+	screenshotErr error
+
+	// html is the page after the HTML cleaning steps, for the html format. It
+	// is empty for sources that aren't HTML pages, and once the markdown no
+	// longer matches it (a section, outline, or truncation), in which case the
+	// html format renders the markdown instead.
+	html string
+}
+
+// outputFormats are the accepted values of --format and the server's format
+// parameter. The outline format is markdown: the heading tree in place of the
+// content.
+var outputFormats = []string{"markdown", "text", "html", "json", "outline"}
+
+func validateFormat(format string) error {
+	for _, f := range outputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid format %q (want one of: %s)", format, strings.Join(outputFormats, ", "))
+}
+
+// run converts the pipeline's URL, then selects sections and applies the token budget.
+func (p *pipeline) run() (*document, error) {
+	doc, err := p.convertURL()
+	if err != nil {
+		return nil, err
+	}
+	return doc, p.finish(doc)
+}
+
+// finish narrows doc to the requested section, replaces it with its outline or
+// adds a table of contents, then counts its tokens and truncates it to
+// maxTokens if set.
+func (p *pipeline) finish(doc *document) error {
+	doc.meta.Extra = p.extra
+	if p.section != "" {
+		md, err := convert.ExtractSection(doc.markdown, p.section)
+		if err != nil {
+			return err
+		}
+		doc.markdown = md
+		doc.html = ""
+	}
+	if p.outline || p.listSections || p.toc {
+		doc.html = ""
+	}
+	switch {
+	case p.outline:
+		count := p.counter
+		if count == nil {
+			count, _ = tokens.New(tokens.Estimate)
+		}
+		doc.markdown = convert.Outline(doc.markdown, count.Count)
+	case p.listSections:
+		doc.markdown = convert.SectionList(doc.markdown)
+	case p.toc:
+		doc.markdown = convert.TOC(doc.markdown)
+	}
+	// Section lists and tables of contents are outlines, not page content.
+	if sd := doc.meta.StructuredData; sd != nil && p.appendix && !p.listSections && !p.toc {
+		appendix, err := convert.StructuredDataAppendix(sd)
+		if err != nil {
+			return err
+		}
+		doc.markdown = strings.TrimRight(doc.markdown, "\n") + "\n\n" + appendix
+	}
+
+	if p.counter == nil {
+		return nil
+	}
+	if p.maxTokens > 0 {
+		doc.markdown, doc.meta.Truncated = tokens.Truncate(doc.markdown, p.maxTokens, p.counter)
+		if doc.meta.Truncated {
+			doc.html = ""
+		}
+	}
+	doc.meta.Tokens = p.counter.Count(doc.markdown)
+	doc.meta.Tokenizer = p.counter.Name()
+	return nil
+}
+
+func (p *pipeline) convertURL() (*document, error) {
+	start := time.Now()
+	var timing []convert.TimingStep
+
+	// Try a plain HTTP fetch first — skip the browser entirely if the server
+	// supports markdown content negotiation or the URL isn't an HTML page.
+	fetchStart := time.Now()
+	result, err := fetch.Direct(p.fetch)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		timing = append(timing, convert.TimingStep{Name: "fetch", Duration: time.Since(fetchStart)})
+		if err := p.checkStatus(result); err != nil {
+			return nil, err
+		}
+
+		if f, err := feed.Parse([]byte(result.Text), result.Response.FinalURL); err == nil {
+			stepStart := time.Now()
+			md := p.digest(f)
+			timing = append(timing, convert.TimingStep{Name: "feed", Duration: time.Since(stepStart)})
+			timing = append(timing, convert.TimingStep{Name: "total", Duration: time.Since(start)})
+			return &document{markdown: md, meta: p.metadata("feed", result, timing)}, nil
+		}
+
+		// Text resources are converted as-is rather than normalized as markdown.
+		if result.Text != "" {
+			stepStart := time.Now()
+			md := convert.Text(result.Text, result.Response.Headers["content-type"], urlPath(result.Response.FinalURL))
+			timing = append(timing, convert.TimingStep{Name: "convert", Duration: time.Since(stepStart)})
+			timing = append(timing, convert.TimingStep{Name: "total", Duration: time.Since(start)})
+			return &document{markdown: md, meta: p.metadata("text", result, timing)}, nil
+		}
+
+		method, md := "markdown", result.Markdown
+		if result.PDF != nil {
+			method = "pdf"
+			stepStart := time.Now()
+			var err error
+			if md, err = convert.PDF(result.PDF); err != nil {
+				return nil, err
+			}
+			timing = append(timing, convert.TimingStep{Name: "convert", Duration: time.Since(stepStart)})
+		}
+
+		stepStart := time.Now()
+		md, err := convert.FormatMarkdown(md)
+		if err != nil {
+			return nil, err
+		}
+		timing = append(timing, convert.TimingStep{Name: "format", Duration: time.Since(stepStart)})
+		md, timing = p.rewriteLinks(md, timing)
+
+		timing = append(timing, convert.TimingStep{Name: "total", Duration: time.Since(start)})
+		return &document{markdown: md, meta: p.metadata(method, result, timing)}, nil
+	}
+
+	result, err = p.page(p.fetch)
+	if err != nil {
+		return nil, err
+	}
+	timing = append(timing, convert.TimingStep{Name: "fetch", Duration: time.Since(fetchStart)})
+	if err := p.checkStatus(result); err != nil {
+		return nil, err
+	}
+	return p.convertPage(result, "browser", start, timing)
+}
+
+// convertPage runs the HTML cleaning and conversion steps on a rendered or local
+// page. start and timing carry over from fetching it.
+func (p *pipeline) convertPage(result *fetch.Result, method string, start time.Time, timing []convert.TimingStep) (*document, error) {
+	html := result.HTML
+	pageURL := result.Response.FinalURL
+	if pageURL == "" {
+		pageURL = p.fetch.URL
+	}
+
+	// Metadata comes first: JSON-LD lives in the scripts StripHidden removes.
+	var pageMeta convert.PageMeta
+	if p.pageMeta {
+		stepStart := time.Now()
+		pageMeta = convert.ExtractMeta(html, pageURL)
+		timing = append(timing, convert.TimingStep{Name: "metadata", Duration: time.Since(stepStart)})
+	}
+
+	var structured *convert.StructuredData
+	if p.structured {
+		stepStart := time.Now()
+		structured = convert.ExtractStructuredData(html, pageURL)
+		timing = append(timing, convert.TimingStep{Name: "structured_data", Duration: time.Since(stepStart)})
+	}
+
+	stepStart := time.Now()
+	html = convert.StripHidden(html)
+	timing = append(timing, convert.TimingStep{Name: "strip_hidden", Duration: time.Since(stepStart)})
+
+	if !p.keepNav {
+		stepStart = time.Now()
+		html = convert.StripNav(html)
+		timing = append(timing, convert.TimingStep{Name: "strip_nav", Duration: time.Since(stepStart)})
+	}
+
+	if !p.images {
+		stepStart = time.Now()
+		html = convert.StripImages(html)
+		timing = append(timing, convert.TimingStep{Name: "strip_images", Duration: time.Since(stepStart)})
+	}
+
+	if !p.relativeLinks {
+		stepStart = time.Now()
+		html = convert.ResolveURLs(html, pageURL)
+		timing = append(timing, convert.TimingStep{Name: "resolve_urls", Duration: time.Since(stepStart)})
+	}
+
+	cleaned := html
+	var md string
+	var byline convert.Byline
+	var err error
+	stepStart = time.Now()
+	if html == "" {
+		md = ""
+	} else if p.article {
+		md, byline, err = convert.Readability(html)
+	} else {
+		md, err = convert.Full(html)
+	}
+	if err != nil {
+		return nil, err
+	}
+	timing = append(timing, convert.TimingStep{Name: "convert", Duration: time.Since(stepStart)})
+
+	// A head without a title or author falls back to readability's, reusing
+	// the article when there is one.
+	if p.pageMeta && !pageMeta.Complete() && html != "" {
+		if !p.article {
+			stepStart = time.Now()
+			byline = convert.ExtractByline(html)
+			timing = append(timing, convert.TimingStep{Name: "byline", Duration: time.Since(stepStart)})
+		}
+		pageMeta.Fill(byline)
+	}
+
+	stepStart = time.Now()
+	md = convert.StripJunkLinks(md)
+	timing = append(timing, convert.TimingStep{Name: "strip_junk_links", Duration: time.Since(stepStart)})
+
+	stepStart = time.Now()
+	md, err = convert.FormatMarkdown(md)
+	if err != nil {
+		return nil, err
+	}
+	timing = append(timing, convert.TimingStep{Name: "format", Duration: time.Since(stepStart)})
+	md, timing = p.rewriteLinks(md, timing)
+
+	if result.TimedOut {
+		md = fmt.Sprintf("[webmd: page timed out after %s; content may be incomplete]\n\n%s", p.fetch.Timeout, md)
+	}
+
+	timing = append(timing, convert.TimingStep{Name: "total", Duration: time.Since(start)})
+	doc := &document{markdown: md, meta: p.metadata(method, result, timing), html: cleaned}
+	doc.screenshot, doc.screenshotErr = result.Screenshot, result.ScreenshotErr
+	doc.meta.PageMeta = pageMeta
+	doc.meta.StructuredData = structured
+	if result.TimedOut {
+		doc.timeout = &fetch.TimeoutError{URL: p.fetch.URL, Timeout: p.fetch.Timeout, Partial: result.HTML != ""}
+	}
+	return doc, nil
+}
+
+// rewriteLinks applies the link mode and dedup options to converted markdown.
+func (p *pipeline) rewriteLinks(md string, timing []convert.TimingStep) (string, []convert.TimingStep) {
+	if (p.links == "" || p.links == convert.LinksInline) && !p.dedupLinks {
+		return md, timing
+	}
+	mode := p.links
+	if mode == "" {
+		mode = convert.LinksInline
+	}
+	stepStart := time.Now()
+	md = convert.Links(md, mode, p.dedupLinks)
+	return md, append(timing, convert.TimingStep{Name: "links", Duration: time.Since(stepStart)})
+}
+
+// parseMeta parses key=value metadata fields, typing the values with
+// convert.MetaValue.
+func parseMeta(pairs []string) (map[string]any, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	meta := make(map[string]any, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid meta %q (want key=value)", pair)
+		}
+		meta[key] = convert.MetaValue(value)
+	}
+	return meta, nil
+}
+
+// urlPath returns the path component of u, or "" if it doesn't parse.
+func urlPath(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	return parsed.Path
+}
+
+// checkStatus fails the conversion if the upstream status matches --fail-on-status.
+func (p *pipeline) checkStatus(result *fetch.Result) error {
+	if status := result.Response.Status; p.failOnStatus.Match(status) {
+		return &fetch.StatusError{URL: p.fetch.URL, Status: status}
+	}
+	return nil
+}
+
+func (p *pipeline) metadata(method string, result *fetch.Result, timing []convert.TimingStep) convert.Metadata {
+	m := convert.Metadata{
+		SourceURL:   p.fetch.URL,
+		FinalURL:    result.Response.FinalURL,
+		Status:      result.Response.Status,
+		Headers:     result.Response.Headers,
+		FetchMethod: method,
+		TimedOut:    result.TimedOut,
+		Blocked:     result.Blocked,
+		Timing:      timing,
+	}
+	for _, r := range result.Response.Redirects {
+		m.Redirects = append(m.Redirects, convert.Redirect{URL: r.URL, Status: r.Status})
+	}
+	return m
+}
+
+// chunks splits doc's markdown into chunks of at most size tokens, each
+// labeled with the source URL.
+func (p *pipeline) chunks(doc *document, size, overlap int) []chunk.Chunk {
+	chunks := chunk.Split(doc.markdown, size, overlap, p.counter)
+	for i := range chunks {
+		chunks[i].Source = doc.meta.SourceURL
+	}
+	return chunks
+}
+
+// renderChunks formats chunks as JSON Lines, one chunk per line.
+func renderChunks(chunks []chunk.Chunk) (string, error) {
+	var b strings.Builder
+	for _, c := range chunks {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return "", fmt.Errorf("encoding JSON: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// render formats doc as markdown, plain text, HTML, or JSON. frontmatter is the
+// frontmatter format to prepend to markdown or text, or "" for none.
+func render(doc *document, format, frontmatter string) (string, error) {
+	switch format {
+	case "json":
+		return convert.JSON(doc.meta, doc.markdown)
+	case "html":
+		if doc.html != "" {
+			return convert.Sanitize(doc.html), nil
+		}
+		return convert.MarkdownHTML(doc.markdown)
+	}
+	md := doc.markdown
+	if format == "text" {
+		md = convert.PlainText(md)
+	}
+	if frontmatter != "" {
+		fm, err := convert.FrontmatterAs(doc.meta, frontmatter)
+		if err != nil {
+			return "", err
+		}
+		return fm + md, nil
+	}
+	return md, nil
+}
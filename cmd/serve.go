@@ -2,26 +2,37 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/boozedog/webmd/internal/browser"
+	"github.com/boozedog/webmd/internal/chunk"
 	"github.com/boozedog/webmd/internal/convert"
 	"github.com/boozedog/webmd/internal/fetch"
 	"github.com/boozedog/webmd/internal/preview"
+	"github.com/boozedog/webmd/internal/tokens"
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
 	"github.com/spf13/cobra"
 )
 
 func newServeCmd() *cobra.Command {
 	var (
-		flagPort int
-		flagHost string
+		flagPort        int
+		flagHost        string
+		flagMaxSessions int
+		flagSessionIdle time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -30,33 +41,47 @@ func newServeCmd() *cobra.Command {
 		Long:  "Launch a persistent HTTP server that keeps a headless Chrome instance running.\nSend GET /?url=https://example.com to convert pages to markdown.",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runServe(cmd, flagHost, flagPort)
+			return runServe(cmd, flagHost, flagPort, flagMaxSessions, flagSessionIdle)
 		},
 	}
 
 	cmd.Flags().IntVar(&flagPort, "port", 8080, "Port to listen on")
 	cmd.Flags().StringVar(&flagHost, "host", "0.0.0.0", "Host to bind to")
+	cmd.Flags().IntVar(&flagMaxSessions, "max-sessions", 32, "Maximum number of named sessions open at once")
+	cmd.Flags().DurationVar(&flagSessionIdle, "session-idle", 30*time.Minute, "Dispose of named sessions unused for this long")
 
 	return cmd
 }
 
-func runServe(cmd *cobra.Command, host string, port int) error {
-	controlURL, cleanup, err := browser.Launch(browser.Options{
-		BrowserPath: flagBrowserPath,
-		NoDownload:  flagNoDownload,
-	})
+func runServe(cmd *cobra.Command, host string, port, maxSessions int, sessionIdle time.Duration) error {
+	blockDomains, err := blockedDomains()
+	if err != nil {
+		return err
+	}
+
+	browserOpts := browserOptions()
+	if err := browserOpts.Validate(); err != nil {
+		return err
+	}
+	controlURL, cleanup, err := browser.Launch(browserOpts)
 	if err != nil {
 		return err
 	}
 	defer cleanup()
 
-	b := rod.New().ControlURL(controlURL)
-	if err := b.Connect(); err != nil {
-		return fmt.Errorf("connecting to browser: %w", err)
+	conn, err := browser.Connect(controlURL, browserOpts)
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
+
+	sess := newSessions(maxSessions, sessionIdle)
+	defer sess.close()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /", handleConvert(b))
+	mux.HandleFunc("GET /", handleConvert(conn, sess, blockDomains))
+	mux.HandleFunc("POST /convert", handleConvertHTML)
+	mux.HandleFunc("DELETE /sessions/{name}", handleDeleteSession(sess))
 
 	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	srv := &http.Server{Addr: addr, Handler: mux}
@@ -68,6 +93,7 @@ func runServe(cmd *cobra.Command, host string, port int) error {
 		<-ctx.Done()
 		srv.Shutdown(context.Background())
 	}()
+	go sess.expireIdle(ctx)
 
 	fmt.Fprintf(cmd.OutOrStderr(), "webmd server listening on %s\n", addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -76,163 +102,686 @@ func runServe(cmd *cobra.Command, host string, port int) error {
 	return nil
 }
 
-func handleConvert(b *rod.Browser) http.HandlerFunc {
+// sessions holds named persistent browser contexts for clients that want cookies
+// and storage to carry over between requests (the 'session' query parameter).
+// Requests without a session each get a fresh incognito context instead.
+// At most max sessions are open at once, and sessions unused for idle are
+// disposed of.
+type sessions struct {
+	mu       sync.Mutex
+	contexts map[string]*session
+	max      int
+	idle     time.Duration
+}
+
+type session struct {
+	browser  *rod.Browser
+	proxy    string
+	lastUsed time.Time
+}
+
+// errTooManySessions means a new session was requested while max were open.
+// A max of 0 disables sessions.
+var errTooManySessions = errors.New("too many open sessions; delete one or retry later")
+
+func newSessions(max int, idle time.Duration) *sessions {
+	return &sessions{contexts: map[string]*session{}, max: max, idle: idle}
+}
+
+// get returns the browser context for the named session, creating it on b on first use.
+// A session is bound to the proxy it was created with.
+func (s *sessions) get(b *rod.Browser, name, proxy string) (*rod.Browser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sess, ok := s.contexts[name]; ok {
+		if sess.proxy != proxy {
+			return nil, fmt.Errorf("session %q was created with a different proxy", name)
+		}
+		sess.lastUsed = time.Now()
+		return sess.browser, nil
+	}
+	s.expire(time.Now())
+	if len(s.contexts) >= s.max {
+		return nil, errTooManySessions
+	}
+	ctx, err := browser.NewContext(b, proxy)
+	if err != nil {
+		return nil, err
+	}
+	s.contexts[name] = &session{browser: ctx, proxy: proxy, lastUsed: time.Now()}
+	return ctx, nil
+}
+
+// expire disposes of sessions unused since before now minus the idle time.
+// The caller must hold s.mu.
+func (s *sessions) expire(now time.Time) {
+	if s.idle <= 0 {
+		return
+	}
+	for name, sess := range s.contexts {
+		if now.Sub(sess.lastUsed) >= s.idle {
+			sess.browser.Close()
+			delete(s.contexts, name)
+		}
+	}
+}
+
+// expireIdle disposes of idle sessions once a minute until ctx is done.
+func (s *sessions) expireIdle(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			s.expire(now)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// remove disposes of the named session. It reports whether the session existed.
+func (s *sessions) remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.contexts[name]
+	if !ok {
+		return false
+	}
+	sess.browser.Close()
+	delete(s.contexts, name)
+	return true
+}
+
+// reset forgets all sessions after the browser connection was re-established.
+// Their contexts are disposed through the new connection b where they still exist.
+func (s *sessions) reset(b *rod.Browser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, sess := range s.contexts {
+		_ = proto.TargetDisposeBrowserContext{BrowserContextID: sess.browser.BrowserContextID}.Call(b)
+		delete(s.contexts, name)
+	}
+}
+
+// close disposes of all session browser contexts.
+func (s *sessions) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, sess := range s.contexts {
+		sess.browser.Close()
+		delete(s.contexts, name)
+	}
+}
+
+func handleDeleteSession(sess *sessions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !sess.remove(r.PathValue("name")) {
+			writeProblem(w, http.StatusNotFound, "session_not_found", "session not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// maxHTMLBody limits the size of HTML posted to /convert, matching the limit
+// on responses fetched without a browser.
+const maxHTMLBody = fetch.MaxBodySize
+
+// handleConvertHTML converts HTML posted in the request body, for clients that
+// already have the page. Nothing is fetched; the optional 'url' parameter is the
+// page's address, reported as its source.
+func handleConvertHTML(w http.ResponseWriter, r *http.Request) {
+	base := r.URL.Query().Get("url")
+	if base != "" {
+		if u, err := url.Parse(base); err != nil || !u.IsAbs() {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid url %q (want an absolute URL)", base))
+			return
+		}
+	}
+	format, err := queryFormat(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	links, err := queryLinks(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	counter, maxTokens, err := queryTokens(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	chunkSize, chunkOverlap, err := queryChunks(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	section, listSections, err := querySections(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	frontmatter, err := queryFrontmatter(r)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	extra, err := parseMeta(r.URL.Query()["meta"])
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTMLBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, http.StatusRequestEntityTooLarge, "invalid_parameter", fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("reading request body: %v", err))
+		return
+	}
+	if len(body) == 0 {
+		writeProblem(w, http.StatusBadRequest, "invalid_parameter", "missing HTML request body")
+		return
+	}
+
+	p := &pipeline{
+		fetch:         fetch.Options{URL: base},
+		article:       queryBool(r, "article"),
+		images:        queryBool(r, "images"),
+		keepNav:       queryBool(r, "keep-nav"),
+		relativeLinks: queryBool(r, "relative-links"),
+		links:         links,
+		dedupLinks:    queryBool(r, "dedup-links"),
+		counter:       counter,
+		maxTokens:     maxTokens,
+		section:       section,
+		listSections:  listSections,
+		toc:           queryBool(r, "toc"),
+		outline:       format == "outline",
+		structured:    queryBool(r, "structured-data"),
+		appendix:      format == "markdown" && chunkSize == 0,
+		pageMeta:      frontmatter != "" || format == "json",
+		extra:         extra,
+	}
+	doc, err := p.runLocal(&localInput{html: string(body), url: base}, false)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if chunkSize > 0 {
+		writeChunks(w, p.chunks(doc, chunkSize, chunkOverlap))
+		return
+	}
+	writeDocument(w, doc, format, frontmatter, queryBool(r, "preview"))
+}
+
+func handleConvert(conn *browser.Conn, sess *sessions, blockDomains []string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		targetURL := r.URL.Query().Get("url")
 		if targetURL == "" {
-			http.Error(w, "missing required 'url' query parameter", http.StatusBadRequest)
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", "missing required 'url' query parameter")
 			return
 		}
 
-		article := r.URL.Query().Has("article") && r.URL.Query().Get("article") != "false" && r.URL.Query().Get("article") != "0"
+		article := queryBool(r, "article")
 
 		timeout := 15 * time.Second
 		if t := r.URL.Query().Get("timeout"); t != "" {
-			if d, err := time.ParseDuration(t); err == nil {
-				timeout = d
+			d, err := time.ParseDuration(t)
+			if err != nil || d < 0 {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid timeout %q (want a duration such as 30s)", t))
+				return
 			}
+			timeout = d
 		}
 
 		var wait time.Duration
 		if ws := r.URL.Query().Get("wait"); ws != "" {
-			if d, err := time.ParseDuration(ws); err == nil {
-				wait = d
+			d, err := time.ParseDuration(ws)
+			if err != nil || d < 0 {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid wait %q (want a duration such as 2s)", ws))
+				return
 			}
+			wait = d
 		}
 
 		userAgent := r.URL.Query().Get("user-agent")
-		images := r.URL.Query().Has("images") && r.URL.Query().Get("images") != "false" && r.URL.Query().Get("images") != "0"
-		mobile := r.URL.Query().Has("mobile") && r.URL.Query().Get("mobile") != "false" && r.URL.Query().Get("mobile") != "0"
-		keepNav := r.URL.Query().Has("keep-nav") && r.URL.Query().Get("keep-nav") != "false" && r.URL.Query().Get("keep-nav") != "0"
-		frontmatter := r.URL.Query().Has("frontmatter") && r.URL.Query().Get("frontmatter") != "false" && r.URL.Query().Get("frontmatter") != "0"
-
-		start := time.Now()
-		var timing []convert.TimingStep
+		images := queryBool(r, "images")
+		mobile := queryBool(r, "mobile")
+		keepNav := queryBool(r, "keep-nav")
+		frontmatter, err := queryFrontmatter(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		extra, err := parseMeta(r.URL.Query()["meta"])
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
 
-		// Try markdown content negotiation first.
-		fetchStart := time.Now()
-		if md := fetch.Markdown(targetURL, timeout); md != "" {
-			timing = append(timing, convert.TimingStep{Name: "fetch", Duration: time.Since(fetchStart)})
+		// Device, localization and viewport parameters override the server-wide flags.
+		lang, timezone, windowSize, geolocation := flagLang, flagTimezone, flagWindowSize, flagGeolocation
+		if v := r.URL.Query().Get("lang"); v != "" {
+			lang = v
+		}
+		if v := r.URL.Query().Get("timezone"); v != "" {
+			timezone = v
+		}
+		if v := r.URL.Query().Get("window-size"); v != "" {
+			windowSize = v
+		}
+		if v := r.URL.Query().Get("geolocation"); v != "" {
+			geolocation = v
+		}
+		device, geo, err := parseEmulation(r.URL.Query().Get("device"), mobile, windowSize, geolocation)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		if err := fetch.ValidateLocale(lang, timezone); err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
 
-			stepStart := time.Now()
-			md, err := convert.FormatMarkdown(md)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+		// A per-request proxy overrides the server-wide --proxy; it is applied
+		// to the request's own browser context.
+		proxy := flagProxy
+		if p := r.URL.Query().Get("proxy"); p != "" {
+			if _, err := browser.ProxyServer(p); err != nil {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
 				return
 			}
-			timing = append(timing, convert.TimingStep{Name: "format", Duration: time.Since(stepStart)})
-
-			if frontmatter {
-				timing = append(timing, convert.TimingStep{Name: "total", Duration: time.Since(start)})
-				md = convert.Frontmatter(convert.Metadata{
-					SourceURL:   targetURL,
-					FetchMethod: "markdown",
-					Timing:      timing,
-				}) + md
-			}
+			proxy = p
+		}
 
-			wantPreview := r.URL.Query().Has("preview") && r.URL.Query().Get("preview") != "false" && r.URL.Query().Get("preview") != "0"
-			if wantPreview {
-				rendered, err := preview.Render(md)
-				if err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
-					return
-				}
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				w.Write([]byte(rendered))
+		blockTypes := defaultBlockResources
+		if r.URL.Query().Has("block") {
+			blockTypes = nil
+			if bt := r.URL.Query().Get("block"); bt != "" {
+				blockTypes = strings.Split(bt, ",")
+			}
+			if err := fetch.ValidateResourceTypes(blockTypes); err != nil {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
 				return
 			}
+		}
 
-			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			w.Write([]byte(md))
-			return
+		domains := blockDomains
+		if bd := r.URL.Query().Get("block-domains"); bd != "" {
+			domains = append(domains[:len(domains):len(domains)], strings.Split(strings.ToLower(bd), ",")...)
 		}
 
-		result, err := fetch.PageOnBrowser(b, fetch.Options{
-			URL:       targetURL,
-			Timeout:   timeout,
-			Wait:      wait,
-			UserAgent: userAgent,
-			Mobile:    mobile,
-		})
+		format, err := queryFormat(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		links, err := queryLinks(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		counter, maxTokens, err := queryTokens(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		chunkSize, chunkOverlap, err := queryChunks(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		section, listSections, err := querySections(r)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		screenshot, err := queryScreenshot(r, format, chunkSize)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
 			return
 		}
-		timing = append(timing, convert.TimingStep{Name: "fetch", Duration: time.Since(fetchStart)})
 
-		html := result.HTML
+		var failOnStatus fetch.StatusMatcher
+		if fs := r.URL.Query().Get("fail-on-status"); fs != "" {
+			if failOnStatus, err = fetch.ParseStatusMatcher(strings.Split(fs, ",")); err != nil {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+				return
+			}
+		}
 
-		stepStart := time.Now()
-		html = convert.StripHidden(html)
-		timing = append(timing, convert.TimingStep{Name: "strip_hidden", Duration: time.Since(stepStart)})
+		since, err := parseSince(r.URL.Query().Get("since"))
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		fetchEntries := queryBool(r, "fetch-entries")
+		maxEntries := defaultMaxEntries
+		if s := r.URL.Query().Get("max-entries"); s != "" {
+			if maxEntries, err = strconv.Atoi(s); err != nil || maxEntries < 0 {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid max-entries %q (want a non-negative integer)", s))
+				return
+			}
+		}
 
-		if !keepNav {
-			stepStart = time.Now()
-			html = convert.StripNav(html)
-			timing = append(timing, convert.TimingStep{Name: "strip_nav", Duration: time.Since(stepStart)})
+		// The browser is only touched once every parameter is valid, so a bad
+		// request neither reconnects nor opens a session. Remote browsers can
+		// drop the connection; reconnect before using it.
+		b, reconnected, err := conn.Ensure()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if reconnected {
+			sess.reset(b)
 		}
 
-		if !images {
-			stepStart = time.Now()
-			html = convert.StripImages(html)
-			timing = append(timing, convert.TimingStep{Name: "strip_images", Duration: time.Since(stepStart)})
+		// Each request gets a fresh incognito context unless the client asks
+		// for a named session to keep cookies and storage between requests.
+		// This holds with --user-data-dir too: the profile only backs the
+		// browser's default context, which is never shared between clients.
+		tabs := b
+		persistent := false
+		if name := r.URL.Query().Get("session"); name != "" {
+			ctx, err := sess.get(b, name, proxy)
+			if errors.Is(err, errTooManySessions) {
+				writeProblem(w, http.StatusTooManyRequests, "too_many_sessions", err.Error())
+				return
+			}
+			if err != nil {
+				writeProblem(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+				return
+			}
+			tabs, persistent = ctx, true
 		}
 
-		var md string
-		stepStart = time.Now()
-		if html == "" {
-			md = ""
-		} else if article {
-			md, err = convert.Readability(html)
-		} else {
-			md, err = convert.Full(html)
+		p := &pipeline{
+			fetch: fetch.Options{
+				URL:        targetURL,
+				Timeout:    timeout,
+				Wait:       wait,
+				UserAgent:  userAgent,
+				Proxy:      proxy,
+				Persistent: persistent,
+
+				Device:      device,
+				Lang:        lang,
+				Timezone:    timezone,
+				Geolocation: geo,
+
+				BlockResources: blockedResources(blockTypes, images),
+				BlockDomains:   domains,
+
+				Screenshot: screenshot,
+			},
+			article:       article,
+			images:        images,
+			keepNav:       keepNav,
+			relativeLinks: queryBool(r, "relative-links"),
+			links:         links,
+			dedupLinks:    queryBool(r, "dedup-links"),
+			failOnStatus:  failOnStatus,
+			since:         since,
+			fetchEntries:  fetchEntries,
+			maxEntries:    maxEntries,
+			counter:       counter,
+			maxTokens:     maxTokens,
+			section:       section,
+			listSections:  listSections,
+			toc:           queryBool(r, "toc"),
+			outline:       format == "outline",
+			structured:    queryBool(r, "structured-data"),
+			appendix:      format == "markdown" && chunkSize == 0,
+			pageMeta:      frontmatter != "" || format == "json",
+			extra:         extra,
+			page: func(opts fetch.Options) (*fetch.Result, error) {
+				return fetch.PageOnBrowser(tabs, opts)
+			},
 		}
+
+		doc, err := p.run()
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
-		timing = append(timing, convert.TimingStep{Name: "convert", Duration: time.Since(stepStart)})
+		// Partial content from a timed-out page is still served; an empty page is not.
+		if doc.timeout != nil && !doc.timeout.Partial {
+			writeError(w, doc.timeout)
+			return
+		}
+		doc.meta.Screenshot = doc.screenshot
+		if doc.screenshotErr != nil {
+			doc.meta.ScreenshotErr = doc.screenshotErr.Error()
+		}
 
-		stepStart = time.Now()
-		md = convert.StripJunkLinks(md)
-		timing = append(timing, convert.TimingStep{Name: "strip_junk_links", Duration: time.Since(stepStart)})
+		if chunkSize > 0 {
+			writeChunks(w, p.chunks(doc, chunkSize, chunkOverlap))
+			return
+		}
+		writeDocument(w, doc, format, frontmatter, queryBool(r, "preview"))
+	}
+}
 
-		stepStart = time.Now()
-		md, err = convert.FormatMarkdown(md)
+// writeDocument responds with doc in the requested format, or as rendered HTML for previews.
+func writeDocument(w http.ResponseWriter, doc *document, format, frontmatter string, wantPreview bool) {
+	if wantPreview {
+		md, _ := render(doc, "markdown", frontmatter)
+		rendered, err := preview.Render(md)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
-		timing = append(timing, convert.TimingStep{Name: "format", Duration: time.Since(stepStart)})
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(rendered))
+		return
+	}
 
-		if result.TimedOut {
-			md = fmt.Sprintf("[webmd: page timed out after %s; content may be incomplete]\n\n%s", timeout, md)
+	out, err := render(doc, format, frontmatter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Write([]byte(out))
+}
+
+// writeChunks responds with chunks as a JSON array.
+func writeChunks(w http.ResponseWriter, chunks []chunk.Chunk) {
+	if chunks == nil {
+		chunks = []chunk.Chunk{}
+	}
+	data, err := json.MarshalIndent(chunks, "", "  ")
+	if err != nil {
+		writeError(w, fmt.Errorf("encoding JSON: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+// queryFormat returns the validated format parameter, defaulting to markdown.
+func queryFormat(r *http.Request) (string, error) {
+	f := r.URL.Query().Get("format")
+	if f == "" {
+		return "markdown", nil
+	}
+	return f, validateFormat(f)
+}
+
+// queryLinks returns the validated links parameter, defaulting to inline.
+func queryLinks(r *http.Request) (string, error) {
+	l := r.URL.Query().Get("links")
+	if l == "" {
+		return convert.LinksInline, nil
+	}
+	return l, convert.ValidateLinkMode(l)
+}
+
+// queryTokens parses the tokenizer and max-tokens parameters.
+func queryTokens(r *http.Request) (tokens.Counter, int, error) {
+	name := r.URL.Query().Get("tokenizer")
+	if name == "" {
+		name = tokens.DefaultEncoding
+	}
+	counter, err := tokens.New(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	var max int
+	if s := r.URL.Query().Get("max-tokens"); s != "" {
+		if max, err = strconv.Atoi(s); err != nil || max < 0 {
+			return nil, 0, fmt.Errorf("invalid max-tokens %q (want a non-negative integer)", s)
 		}
+	}
+	return counter, max, nil
+}
 
-		if frontmatter {
-			timing = append(timing, convert.TimingStep{Name: "total", Duration: time.Since(start)})
-			md = convert.Frontmatter(convert.Metadata{
-				SourceURL:   targetURL,
-				FetchMethod: "browser",
-				TimedOut:    result.TimedOut,
-				Timing:      timing,
-			}) + md
+// queryFrontmatter returns the frontmatter format requested by the frontmatter
+// and frontmatter-format parameters, or "" for none. Giving a format implies
+// frontmatter.
+func queryFrontmatter(r *http.Request) (string, error) {
+	format := r.URL.Query().Get("frontmatter-format")
+	if format == "" {
+		if !queryBool(r, "frontmatter") {
+			return "", nil
 		}
+		return convert.FrontmatterYAML, nil
+	}
+	return format, convert.ValidateFrontmatterFormat(format)
+}
 
-		wantPreview := r.URL.Query().Has("preview") && r.URL.Query().Get("preview") != "false" && r.URL.Query().Get("preview") != "0"
-		if wantPreview {
-			rendered, err := preview.Render(md)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			w.Write([]byte(rendered))
-			return
+// queryScreenshot parses the screenshot parameters, or returns nil if no
+// screenshot was requested. The screenshot is a field of the JSON output, so
+// it requires format=json and can't be combined with chunking.
+func queryScreenshot(r *http.Request, format string, chunkSize int) (*fetch.Screenshot, error) {
+	q := r.URL.Query()
+	if !queryBool(r, "screenshot") {
+		return nil, nil
+	}
+	if format != "json" {
+		return nil, errors.New("screenshot requires format=json")
+	}
+	if chunkSize > 0 {
+		return nil, errors.New("screenshot can't be combined with chunk-size")
+	}
+	s := &fetch.Screenshot{FullPage: queryBool(r, "screenshot-full-page"), Format: fetch.ScreenshotPNG}
+	if f := q.Get("screenshot-format"); f != "" {
+		s.Format = f
+	}
+	if v := q.Get("screenshot-quality"); v != "" {
+		quality, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid screenshot-quality %q (want 1 to 100)", v)
 		}
+		s.Quality = quality
+	}
+	return s, s.Validate()
+}
 
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.Write([]byte(md))
+// querySections parses the section and sections parameters.
+func querySections(r *http.Request) (section string, list bool, err error) {
+	q := r.URL.Query()
+	if s := q.Get("sections"); s != "" && s != "list" {
+		return "", false, fmt.Errorf("invalid sections %q (want list)", s)
+	}
+	section = q.Get("section")
+	if section != "" {
+		err = convert.ValidateSectionQuery(section)
+	}
+	return section, q.Get("sections") == "list", err
+}
+
+// queryChunks parses the chunk-size and chunk-overlap parameters. A size of 0
+// means no chunking.
+func queryChunks(r *http.Request) (size, overlap int, err error) {
+	q := r.URL.Query()
+	if !q.Has("chunk-size") && !q.Has("chunk-overlap") {
+		return 0, 0, nil
+	}
+	if size, err = strconv.Atoi(q.Get("chunk-size")); err != nil {
+		return 0, 0, fmt.Errorf("invalid chunk-size %q (want a positive integer)", q.Get("chunk-size"))
+	}
+	if s := q.Get("chunk-overlap"); s != "" {
+		if overlap, err = strconv.Atoi(s); err != nil {
+			return 0, 0, fmt.Errorf("invalid chunk-overlap %q (want a non-negative integer)", s)
+		}
 	}
+	return size, overlap, chunk.Validate(size, overlap)
+}
+
+// queryBool reports whether a boolean query parameter is set. A bare name
+// counts as true; "false" and "0" as false.
+func queryBool(r *http.Request, name string) bool {
+	q := r.URL.Query()
+	return q.Has(name) && q.Get(name) != "false" && q.Get(name) != "0"
+}
+
+// problem is an RFC 9457 problem-details body. Code is a stable identifier
+// clients can branch on; it matches the codes printed by --json-errors.
+type problem struct {
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	Status         int    `json:"status"`
+	Detail         string `json:"detail"`
+	Code           string `json:"code"`
+	URL            string `json:"url,omitempty"`
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	sendProblem(w, problem{Status: status, Detail: detail, Code: code})
+}
+
+// writeError responds with the HTTP status matching err: 502 when the browser or
+// the page could not be reached, 504 on timeouts, the upstream status when it
+// matched fail-on-status, and 500 otherwise.
+func writeError(w http.ResponseWriter, err error) {
+	info := classify(err)
+	p := problem{Status: http.StatusInternalServerError, Detail: info.Message, Code: info.Code, URL: info.URL, UpstreamStatus: info.Status}
+	switch info.ExitCode {
+	case ExitBrowser, ExitNavigation:
+		p.Status = http.StatusBadGateway
+	case ExitTimeout:
+		p.Status = http.StatusGatewayTimeout
+	case ExitSection:
+		p.Status = http.StatusNotFound
+	case ExitStatus:
+		// Pass matched upstream errors through so clients see the real failure.
+		// Other matched statuses would read as success, a redirect without a
+		// Location, or an interim response, so they become a 502.
+		p.Status = http.StatusBadGateway
+		if info.Status >= 400 && info.Status < 600 {
+			p.Status = info.Status
+		}
+	}
+	sendProblem(w, p)
+}
+
+func sendProblem(w http.ResponseWriter, p problem) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
 }
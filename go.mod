@@ -3,22 +3,28 @@ module github.com/boozedog/webmd
 go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/JohannesKaufmann/html-to-markdown/v2 v2.5.0
 	github.com/go-rod/rod v0.116.2
+	github.com/ledongthuc/pdf v0.0.0-20260907135840-6c8c28e0e8a0
 	github.com/mackee/go-readability v0.3.1
 	github.com/spf13/cobra v1.10.2
+	github.com/teekennedy/goldmark-markdown v0.5.1
+	github.com/tiktoken-go/tokenizer v0.7.0
 	github.com/yuin/goldmark v1.7.16
+	go.yaml.in/yaml/v3 v3.0.5
+	golang.org/x/net v0.47.0
+	golang.org/x/text v0.31.0
 )
 
 require (
 	github.com/JohannesKaufmann/dom v0.2.0 // indirect
+	github.com/dlclark/regexp2 v1.11.5 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
-	github.com/teekennedy/goldmark-markdown v0.5.1 // indirect
 	github.com/ysmood/fetchup v0.2.3 // indirect
 	github.com/ysmood/goob v0.4.0 // indirect
 	github.com/ysmood/got v0.40.0 // indirect
 	github.com/ysmood/gson v0.7.3 // indirect
 	github.com/ysmood/leakless v0.9.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 )
@@ -1,20 +1,68 @@
 package browser
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
 )
 
 type Options struct {
 	BrowserPath string
 	NoDownload  bool
+	Proxy       string   // http, https, or socks5 URL; http and https may carry credentials
+	CDPURL      string   // Remote browser to connect to instead of launching one
+	UserDataDir string   // Persistent profile directory; a temporary one is used if empty
+	Flags       []string // Extra Chrome flags, e.g. "--disable-gpu" or "--force-device-scale-factor=2"
 }
 
+// Validate checks the proxy and rejects launch options combined with a remote
+// browser, which is already running with its own flags and profile.
+func (o Options) Validate() error {
+	if o.Proxy != "" {
+		if _, err := ProxyServer(o.Proxy); err != nil {
+			return err
+		}
+	}
+	if cdpURL(o) == "" {
+		return nil
+	}
+	if len(o.Flags) > 0 {
+		return errors.New("--chrome-flag can't be used with a remote browser (--cdp-url or WEBMD_CDP_URL)")
+	}
+	if o.UserDataDir != "" {
+		return errors.New("--user-data-dir can't be used with a remote browser (--cdp-url or WEBMD_CDP_URL)")
+	}
+	if o.BrowserPath != "" {
+		return errors.New("--browser-path can't be used with a remote browser (--cdp-url or WEBMD_CDP_URL)")
+	}
+	return nil
+}
+
+// LaunchError reports that a browser could not be launched or connected to.
+type LaunchError struct {
+	Err error
+}
+
+func (e *LaunchError) Error() string { return e.Err.Error() }
+func (e *LaunchError) Unwrap() error { return e.Err }
+
 // Launch starts a headless Chrome instance and returns the DevTools control URL
 // along with a cleanup function that should be deferred.
+// If a remote browser is configured via opts.CDPURL or WEBMD_CDP_URL, nothing is
+// launched: the resolved remote URL is returned and cleanup is a no-op.
 func Launch(opts Options) (controlURL string, cleanup func(), err error) {
+	if remote := cdpURL(opts); remote != "" {
+		u, err := ResolveCDPURL(remote)
+		if err != nil {
+			return "", nil, &LaunchError{Err: err}
+		}
+		return u, func() {}, nil
+	}
+
 	path := opts.BrowserPath
 	if path == "" {
 		path = os.Getenv("WEBMD_BROWSER_PATH")
@@ -28,7 +76,7 @@ func Launch(opts Options) (controlURL string, cleanup func(), err error) {
 	case opts.NoDownload:
 		found, ok := launcher.LookPath()
 		if !ok {
-			return "", nil, fmt.Errorf("no system Chrome/Chromium found (auto-download disabled)")
+			return "", nil, &LaunchError{Err: errors.New("no system Chrome/Chromium found (auto-download disabled)")}
 		}
 		l = launcher.New().Bin(found)
 	default:
@@ -42,14 +90,38 @@ func Launch(opts Options) (controlURL string, cleanup func(), err error) {
 
 	l = l.Headless(true)
 
+	if opts.Proxy != "" {
+		server, err := ProxyServer(opts.Proxy)
+		if err != nil {
+			return "", nil, err
+		}
+		l = l.Proxy(server)
+	}
+
 	// In containers, Chrome needs --no-sandbox
 	if inContainer() {
 		l = l.NoSandbox(true)
 	}
 
+	if opts.UserDataDir != "" {
+		l = l.UserDataDir(opts.UserDataDir)
+	}
+
+	for _, f := range opts.Flags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(f, "-"), "=")
+		if name == "" {
+			return "", nil, fmt.Errorf("invalid Chrome flag %q", f)
+		}
+		if hasValue {
+			l = l.Set(flags.Flag(name), value)
+		} else {
+			l = l.Set(flags.Flag(name))
+		}
+	}
+
 	u, err := l.Launch()
 	if err != nil {
-		return "", nil, fmt.Errorf("launching browser: %w", err)
+		return "", nil, &LaunchError{Err: fmt.Errorf("launching browser: %w", err)}
 	}
 
 	cleanup = func() {
@@ -0,0 +1,127 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/cdp"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Conn is a DevTools connection to a browser that can be re-established if it
+// drops, which matters for long-lived connections to remote browsers.
+type Conn struct {
+	controlURL string
+	cdpURL     string // set for remote browsers; re-resolved on reconnect
+
+	mu      sync.Mutex
+	browser *rod.Browser
+	ws      *cdp.WebSocket
+}
+
+// Connect opens a DevTools connection to the browser at controlURL. When a remote
+// CDP URL is configured in opts (or WEBMD_CDP_URL), reconnects resolve it again so
+// a restarted remote browser is picked up. Closing the Conn only disconnects; it
+// never closes the browser itself.
+func Connect(controlURL string, opts Options) (*Conn, error) {
+	c := &Conn{controlURL: controlURL, cdpURL: cdpURL(opts)}
+	if err := c.dial(controlURL); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) dial(u string) error {
+	ws := &cdp.WebSocket{}
+	if err := ws.Connect(context.Background(), u, nil); err != nil {
+		return &LaunchError{Err: fmt.Errorf("connecting to browser: %w", err)}
+	}
+	b := rod.New().Client(cdp.New().Start(ws))
+	if err := b.Connect(); err != nil {
+		ws.Close()
+		return &LaunchError{Err: fmt.Errorf("connecting to browser: %w", err)}
+	}
+	c.browser, c.ws = b, ws
+	return nil
+}
+
+// Browser returns the current browser connection.
+func (c *Conn) Browser() *rod.Browser {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.browser
+}
+
+// Ensure checks that the connection is alive and reconnects if it is not.
+// It returns the (possibly new) browser and whether a reconnect happened, in
+// which case browser contexts created on the old connection may be gone.
+// The probe runs without holding the lock, so concurrent callers don't queue
+// behind each other; only one of them reconnects a dead connection.
+func (c *Conn) Ensure() (b *rod.Browser, reconnected bool, err error) {
+	current := c.Browser()
+	if alive(current) {
+		return current, false, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.browser != current {
+		return c.browser, false, nil // another caller already reconnected
+	}
+
+	c.ws.Close()
+	u := c.controlURL
+	if c.cdpURL != "" {
+		if u, err = ResolveCDPURL(c.cdpURL); err != nil {
+			return nil, false, &LaunchError{Err: err}
+		}
+	}
+	if err := c.dial(u); err != nil {
+		return nil, false, fmt.Errorf("reconnecting: %w", err)
+	}
+	c.controlURL = u
+	return c.browser, true, nil
+}
+
+// Close disconnects from the browser.
+func (c *Conn) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ws.Close()
+}
+
+// alive probes the connection with a cheap DevTools call.
+func alive(b *rod.Browser) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := proto.BrowserGetVersion{}.Call(b.Context(ctx))
+	return err == nil
+}
+
+// cdpURL returns the remote browser URL from opts or WEBMD_CDP_URL, if any.
+func cdpURL(opts Options) string {
+	if opts.CDPURL != "" {
+		return opts.CDPURL
+	}
+	return os.Getenv("WEBMD_CDP_URL")
+}
+
+// ResolveCDPURL turns a remote browser URL into a DevTools WebSocket URL.
+// ws:// and wss:// URLs are used as-is; http(s) URLs and bare host:port are
+// resolved through the browser's /json/version endpoint.
+func ResolveCDPURL(u string) (string, error) {
+	if strings.HasPrefix(u, "ws://") || strings.HasPrefix(u, "wss://") {
+		return u, nil
+	}
+	resolved, err := launcher.ResolveURL(u)
+	if err != nil {
+		return "", fmt.Errorf("resolving CDP URL %s: %w", u, err)
+	}
+	return resolved, nil
+}
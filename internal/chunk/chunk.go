@@ -0,0 +1,207 @@
+// Package chunk splits markdown into heading-aware chunks for embedding.
+package chunk
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/boozedog/webmd/internal/mdblock"
+	"github.com/boozedog/webmd/internal/tokens"
+)
+
+// Chunk is a contiguous piece of the markdown: Text is md[Offset:Offset+len(Text)].
+type Chunk struct {
+	Index    int      `json:"index"`
+	Source   string   `json:"source,omitempty"`
+	Headings []string `json:"headings"` // Path of headings the chunk is under, outermost first
+	Offset   int      `json:"offset"`   // Byte offset of Text in the markdown
+	Tokens   int      `json:"tokens"`
+	Text     string   `json:"text"`
+}
+
+// Validate checks chunk options: size must be positive and overlap smaller than size.
+func Validate(size, overlap int) error {
+	if size <= 0 {
+		return fmt.Errorf("invalid chunk size %d (must be positive)", size)
+	}
+	if overlap < 0 || overlap >= size {
+		return fmt.Errorf("invalid chunk overlap %d (must be at least 0 and less than the chunk size %d)", overlap, size)
+	}
+	return nil
+}
+
+// unit is a piece of markdown that is never split: a block, or a sentence or
+// list item of a block too large for one chunk (or, failing those, a run of
+// its lines or words).
+type unit struct {
+	start, end int
+	tokens     int
+	heading    bool
+	path       []string
+}
+
+// Split cuts md into chunks of at most size tokens as counted by c. A chunk
+// never spans two sections, so every chunk has a single heading path, and
+// chunks only break between blocks — or between sentences or list items of a
+// block that doesn't fit on its own, and then between lines or words of those
+// that still don't. Code blocks and tables are kept whole even if that makes a
+// chunk larger than size. Consecutive chunks in a section share
+// up to overlap tokens.
+func Split(md string, size, overlap int, c tokens.Counter) []Chunk {
+	units := split(md, size, c)
+
+	var chunks []Chunk
+	emit := func(from, to int) {
+		start, end := units[from].start, units[to-1].end
+		text := md[start:end]
+		chunks = append(chunks, Chunk{
+			Index:    len(chunks),
+			Headings: units[to-1].path,
+			Offset:   start,
+			Tokens:   c.Count(text),
+			Text:     text,
+		})
+	}
+
+	first := 0   // first unit of the current chunk
+	content := 0 // first unit of the current chunk that isn't overlap
+	used := 0
+	for i, u := range units {
+		// Headings start a new chunk unless the chunk so far is only headings,
+		// which are kept with the content that follows them.
+		hasText := hasContent(units[content:i])
+		newSection := u.heading && hasText
+		if newSection || (hasText && used+u.tokens > size) {
+			emit(first, i)
+			first, content, used = i, i, 0
+			if !newSection {
+				// Carry the tail of the previous chunk over, within the section.
+				for first > 0 && !units[first-1].heading && used+units[first-1].tokens <= overlap && used+units[first-1].tokens+u.tokens <= size {
+					first--
+					used += units[first].tokens
+				}
+			}
+		}
+		used += u.tokens
+	}
+	if len(units) > 0 {
+		emit(first, len(units))
+	}
+	return chunks
+}
+
+func hasContent(units []unit) bool {
+	for _, u := range units {
+		if !u.heading {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	listItemRe = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s`)
+	lineRe     = regexp.MustCompile(`[^\n]+`)
+	wordRe     = regexp.MustCompile(`\S+`)
+)
+
+// split breaks md into units, tracking the heading path of each.
+func split(md string, size int, c tokens.Counter) []unit {
+	var units []unit
+	var path []string
+	var levels []int
+	for _, b := range mdblock.Split(md) {
+		text := md[b.Start:b.End]
+		if m := headingRe.FindStringSubmatch(text); m != nil && !strings.Contains(text, "\n") {
+			level := len(m[1])
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels, path = levels[:len(levels)-1], path[:len(path)-1]
+			}
+			levels = append(levels, level)
+			path = append(path[:len(path):len(path)], m[2])
+			units = append(units, unit{start: b.Start, end: b.End, tokens: c.Count(text) + 1, heading: true, path: path})
+			continue
+		}
+
+		n := c.Count(text) + 1 // +1 for the blank line between blocks
+		if n <= size || b.Fenced || isTable(text) {
+			units = append(units, unit{start: b.Start, end: b.End, tokens: n, path: path})
+			continue
+		}
+		for _, p := range fit(text, pieces(text), size, c) {
+			units = append(units, unit{start: b.Start + p[0], end: b.Start + p[1], tokens: c.Count(text[p[0]:p[1]]) + 1, path: path})
+		}
+	}
+	return units
+}
+
+func isTable(block string) bool {
+	return strings.HasPrefix(strings.TrimSpace(block), "|")
+}
+
+// fit splits the pieces of block that are still larger than size tokens at
+// line breaks, then at spaces. Only a single word can stay too large.
+func fit(block string, pieces [][2]int, size int, c tokens.Counter) [][2]int {
+	var out [][2]int
+	for _, p := range pieces {
+		if c.Count(block[p[0]:p[1]])+1 <= size {
+			out = append(out, p)
+			continue
+		}
+		for _, l := range pack(block, p, lineRe, size, c) {
+			if c.Count(block[l[0]:l[1]])+1 <= size {
+				out = append(out, l)
+				continue
+			}
+			out = append(out, pack(block, l, wordRe, size, c)...)
+		}
+	}
+	return out
+}
+
+// pack cuts the range p of block into the runs re matches, merging consecutive
+// runs while they fit in size tokens.
+func pack(block string, p [2]int, re *regexp.Regexp, size int, c tokens.Counter) [][2]int {
+	var out [][2]int
+	for _, m := range re.FindAllStringIndex(block[p[0]:p[1]], -1) {
+		r := [2]int{p[0] + m[0], p[0] + m[1]}
+		if n := len(out); n > 0 && c.Count(block[out[n-1][0]:r[1]])+1 <= size {
+			out[n-1][1] = r[1]
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// pieces returns the byte ranges of the list items or sentences in a block.
+func pieces(block string) [][2]int {
+	var out [][2]int
+	if listItemRe.MatchString(block) {
+		start := 0
+		for pos := 0; pos < len(block); {
+			nl := strings.IndexByte(block[pos:], '\n')
+			if nl < 0 {
+				break
+			}
+			pos += nl + 1
+			if listItemRe.MatchString(block[pos:]) {
+				out = append(out, [2]int{start, pos - 1})
+				start = pos
+			}
+		}
+		return append(out, [2]int{start, len(block)})
+	}
+
+	start := 0
+	for _, loc := range mdblock.SentenceEnd.FindAllStringIndex(block, -1) {
+		if loc[1] == len(block) {
+			break
+		}
+		out = append(out, [2]int{start, loc[0] + len(strings.TrimRight(block[loc[0]:loc[1]], " \t\n"))})
+		start = loc[1]
+	}
+	return append(out, [2]int{start, len(block)})
+}
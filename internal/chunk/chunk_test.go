@@ -0,0 +1,154 @@
+package chunk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boozedog/webmd/internal/tokens"
+)
+
+// words counts whitespace-separated words, which keeps expected sizes obvious.
+type words struct{}
+
+func (words) Name() string       { return "words" }
+func (words) Count(s string) int { return len(strings.Fields(s)) }
+
+var _ tokens.Counter = words{}
+
+func TestSplitSections(t *testing.T) {
+	md := "# Guide\n\nIntro text here.\n\n## Install\n\nRun the installer.\n\n## Use\n\nOpen the app.\n"
+	got := Split(md, 100, 0, words{})
+
+	want := []struct {
+		headings []string
+		text     string
+	}{
+		{[]string{"Guide"}, "# Guide\n\nIntro text here."},
+		{[]string{"Guide", "Install"}, "## Install\n\nRun the installer."},
+		{[]string{"Guide", "Use"}, "## Use\n\nOpen the app."},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		c := got[i]
+		if c.Index != i || !reflect.DeepEqual(c.Headings, w.headings) || c.Text != w.text {
+			t.Errorf("chunk %d = %+v, want headings %q text %q", i, c, w.headings, w.text)
+		}
+		if md[c.Offset:c.Offset+len(c.Text)] != c.Text {
+			t.Errorf("chunk %d offset %d doesn't locate its text", i, c.Offset)
+		}
+	}
+}
+
+func TestSplitKeepsHeadingsWithContent(t *testing.T) {
+	md := "# Guide\n\n## Install\n\nRun the installer.\n"
+	got := Split(md, 100, 0, words{})
+	if len(got) != 1 {
+		t.Fatalf("got %d chunks, want 1: %+v", len(got), got)
+	}
+	if want := []string{"Guide", "Install"}; !reflect.DeepEqual(got[0].Headings, want) {
+		t.Errorf("headings = %q, want %q", got[0].Headings, want)
+	}
+}
+
+func TestSplitSize(t *testing.T) {
+	md := "## Notes\n\nOne two three.\n\nFour five six.\n\nSeven eight nine.\n"
+	got := Split(md, 6, 0, words{})
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text)
+		if !reflect.DeepEqual(c.Headings, []string{"Notes"}) {
+			t.Errorf("chunk %d headings = %q", c.Index, c.Headings)
+		}
+	}
+	want := []string{"## Notes\n\nOne two three.", "Four five six.", "Seven eight nine."}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+}
+
+func TestSplitOverlap(t *testing.T) {
+	md := "One two three.\n\nFour five six.\n\nSeven eight nine.\n"
+	got := Split(md, 8, 4, words{})
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text)
+	}
+	want := []string{"One two three.\n\nFour five six.", "Four five six.\n\nSeven eight nine."}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+}
+
+func TestSplitLongParagraph(t *testing.T) {
+	md := "One two three. Four five six. Seven eight nine.\n"
+	got := Split(md, 5, 0, words{})
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text)
+	}
+	want := []string{"One two three.", "Four five six.", "Seven eight nine."}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+}
+
+func TestSplitUnpunctuated(t *testing.T) {
+	md := "one two three four five six seven\neight nine ten\n"
+	got := Split(md, 4, 0, words{})
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text)
+	}
+	want := []string{"one two three", "four five six", "seven", "eight nine ten"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+
+	// The estimate tokenizer used to leave a long paragraph without sentences whole.
+	est, err := tokens.New(tokens.Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md = strings.Repeat("lorem ipsum dolor sit amet ", 20) + "\n"
+	for _, c := range Split(md, 20, 0, est) {
+		if c.Tokens > 20 {
+			t.Errorf("chunk %d has %d tokens, want at most 20: %q", c.Index, c.Tokens, c.Text)
+		}
+	}
+}
+
+func TestSplitKeepsCodeAndTables(t *testing.T) {
+	code := "```go\nfunc main() {\n\n\tfmt.Println(1, 2, 3, 4, 5)\n}\n```"
+	table := "| a | b |\n| --- | --- |\n| 1 | 2 |\n| 3 | 4 |"
+	md := "Intro.\n\n" + code + "\n\n" + table + "\n"
+	got := Split(md, 3, 0, words{})
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text)
+	}
+	want := []string{"Intro.", code, table}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("texts = %q, want %q", texts, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		size, overlap int
+		wantErr       bool
+	}{
+		{500, 0, false},
+		{500, 50, false},
+		{0, 0, true},
+		{500, -1, true},
+		{500, 500, true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.size, tt.overlap); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%d, %d) error = %v, wantErr %v", tt.size, tt.overlap, err, tt.wantErr)
+		}
+	}
+}
@@ -2,12 +2,14 @@ package convert
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
 	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/boozedog/webmd/internal/mdblock"
 	"github.com/mackee/go-readability"
 	goldmarkmd "github.com/teekennedy/goldmark-markdown"
 	"github.com/yuin/goldmark"
@@ -176,21 +178,52 @@ func StripJunkLinks(md string) string {
 	return junkLinkRe.ReplaceAllString(md, "")
 }
 
+// DemoteHeadings shifts ATX headings in md down by n levels, capped at level 6,
+// so a converted document can be nested under a heading of its own.
+// Lines inside fenced code blocks are left alone.
+func DemoteHeadings(md string, n int) string {
+	lines := strings.Split(md, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if mdblock.Closes(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = mdblock.Fence(trimmed); fence != "" {
+			continue
+		}
+		level := len(line) - len(strings.TrimLeft(line, "#"))
+		if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+			continue
+		}
+		lines[i] = strings.Repeat("#", min(level+n, 6)) + line[level:]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Readability extracts the main content from HTML and converts it to markdown.
-// Falls back to Full() if readability cannot extract an article.
-func Readability(html string) (string, error) {
+// Falls back to Full() if readability cannot extract an article. The article's
+// title and byline are also returned, for the page metadata (see PageMeta.Fill).
+func Readability(html string) (string, Byline, error) {
 	article, err := readability.Extract(html, readability.DefaultOptions())
 	if err != nil {
-		return Full(html)
+		md, err := Full(html)
+		return md, Byline{}, err
 	}
+	byline := Byline{Title: strings.TrimSpace(article.Title), Author: strings.TrimSpace(article.Byline)}
 
 	if article.Root == nil {
-		return Full(html)
+		md, err := Full(html)
+		return md, byline, err
 	}
 
 	body := strings.TrimSpace(readability.ToMarkdown(article.Root))
 	if body == "" {
-		return Full(html)
+		md, err := Full(html)
+		return md, byline, err
 	}
 
 	var b strings.Builder
@@ -204,14 +237,22 @@ func Readability(html string) (string, error) {
 	b.WriteString(body)
 	b.WriteByte('\n')
 
-	return b.String(), nil
+	return b.String(), byline, nil
+}
+
+// ConversionError reports that HTML or markdown could not be converted.
+type ConversionError struct {
+	Err error
 }
 
+func (e *ConversionError) Error() string { return e.Err.Error() }
+func (e *ConversionError) Unwrap() error { return e.Err }
+
 // Full converts the entire HTML page to markdown.
 func Full(html string) (string, error) {
 	md, err := htmltomarkdown.ConvertString(html)
 	if err != nil {
-		return "", fmt.Errorf("converting HTML to markdown: %w", err)
+		return "", &ConversionError{Err: fmt.Errorf("converting HTML to markdown: %w", err)}
 	}
 	return md, nil
 }
@@ -224,7 +265,7 @@ func FormatMarkdown(md string) (string, error) {
 
 	var buf bytes.Buffer
 	if err := gm.Convert([]byte(md), &buf); err != nil {
-		return "", fmt.Errorf("formatting markdown: %w", err)
+		return "", &ConversionError{Err: fmt.Errorf("formatting markdown: %w", err)}
 	}
 	return buf.String(), nil
 }
@@ -235,27 +276,52 @@ type TimingStep struct {
 	Duration time.Duration
 }
 
-// Metadata holds information about a fetch for frontmatter generation.
+// MarshalJSON encodes the step as {"name": ..., "duration_ms": ...}.
+func (s TimingStep) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name       string `json:"name"`
+		DurationMS int64  `json:"duration_ms"`
+	}{s.Name, s.Duration.Milliseconds()})
+}
+
+// Redirect is one hop of the redirect chain that led to the converted page.
+type Redirect struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+// Metadata holds information about a fetch for frontmatter and JSON generation.
 type Metadata struct {
-	SourceURL   string
-	FetchMethod string // "markdown" or "browser"
-	TimedOut    bool
-	Timing      []TimingStep
+	SourceURL string `json:"source"`
+	FinalURL  string `json:"final_url,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	PageMeta
+	Redirects   []Redirect        `json:"redirects,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"` // Final response headers, lowercased names
+	FetchMethod string            `json:"fetch_method"`      // "markdown", "pdf", "text", "feed", "local", or "browser"
+	TimedOut    bool              `json:"timed_out"`
+	Blocked     map[string]int    `json:"blocked_requests,omitempty"` // Blocked request counts by reason
+	Tokens      int               `json:"tokens,omitempty"`           // Token count of the markdown
+	Tokenizer   string            `json:"tokenizer,omitempty"`        // Encoding used for Tokens, or "estimate"
+	Truncated   bool              `json:"truncated,omitempty"`        // Markdown was cut to the token budget
+	Timing      []TimingStep      `json:"timing,omitempty"`
+
+	StructuredData *StructuredData `json:"structured_data,omitempty"`  // JSON only; markdown gets an appendix
+	Extra          map[string]any  `json:"meta,omitempty"`             // User-supplied fields (--meta), typed by MetaValue
+	Screenshot     []byte          `json:"screenshot,omitempty"`       // Image of the rendered page, base64 in JSON; server only
+	ScreenshotErr  string          `json:"screenshot_error,omitempty"` // Why the screenshot is missing; server only
 }
 
-// Frontmatter generates a YAML frontmatter block from metadata.
-func Frontmatter(m Metadata) string {
-	var b strings.Builder
-	b.WriteString("---\n")
-	fmt.Fprintf(&b, "source: %s\n", m.SourceURL)
-	fmt.Fprintf(&b, "fetch_method: %s\n", m.FetchMethod)
-	fmt.Fprintf(&b, "timed_out: %t\n", m.TimedOut)
-	if len(m.Timing) > 0 {
-		b.WriteString("timing:\n")
-		for _, step := range m.Timing {
-			fmt.Fprintf(&b, "  %s: %s\n", step.Name, step.Duration.Round(time.Millisecond))
-		}
+// JSON renders metadata and markdown as a single indented JSON object, with the
+// metadata fields at the top level alongside "markdown".
+func JSON(m Metadata, md string) (string, error) {
+	doc := struct {
+		Metadata
+		Markdown string `json:"markdown"`
+	}{m, md}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON: %w", err)
 	}
-	b.WriteString("---\n\n")
-	return b.String()
+	return string(data) + "\n", nil
 }
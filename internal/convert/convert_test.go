@@ -364,3 +364,99 @@ func TestFrontmatterTimedOut(t *testing.T) {
 		t.Error("should not have timing section when no timing steps")
 	}
 }
+
+func TestFrontmatterBlocked(t *testing.T) {
+	m := Metadata{
+		SourceURL:   "https://example.com",
+		FetchMethod: "browser",
+		Blocked:     map[string]int{"image": 12, "domain": 3, "font": 1},
+	}
+	got := Frontmatter(m)
+	want := "blocked_requests:\n  domain: 3\n  font: 1\n  image: 12\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("Frontmatter() missing sorted blocked counts\ngot:  %q\nwant: %q", got, want)
+	}
+
+	m.Blocked = nil
+	if strings.Contains(Frontmatter(m), "blocked_requests:") {
+		t.Error("should not have blocked_requests section when nothing was blocked")
+	}
+}
+
+func TestFrontmatterTokens(t *testing.T) {
+	m := Metadata{SourceURL: "https://example.com", FetchMethod: "browser", Tokens: 1234, Tokenizer: "o200k_base", Truncated: true}
+	got := Frontmatter(m)
+	want := "tokens: 1234\ntokenizer: o200k_base\ntruncated: true\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("Frontmatter() missing token fields\ngot:  %q\nwant: %q", got, want)
+	}
+
+	m = Metadata{SourceURL: "https://example.com", FetchMethod: "browser"}
+	if got := Frontmatter(m); strings.Contains(got, "tokens:") || strings.Contains(got, "truncated:") {
+		t.Errorf("should not have token fields without a tokenizer: %q", got)
+	}
+}
+
+func TestFrontmatterResponse(t *testing.T) {
+	m := Metadata{
+		SourceURL:   "http://example.com/old",
+		FinalURL:    "https://example.com/new",
+		Status:      200,
+		Headers:     map[string]string{"content-type": "text/html; charset=utf-8", "server": "nginx"},
+		Redirects:   []Redirect{{URL: "http://example.com/old", Status: 301}},
+		FetchMethod: "browser",
+	}
+	got := Frontmatter(m)
+
+	for _, want := range []string{
+		"final_url: https://example.com/new\n",
+		"status: 200\n",
+		"content_type: text/html; charset=utf-8\n",
+		"redirects:\n  - url: http://example.com/old\n    status: 301\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Frontmatter() missing %q\ngot: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "nginx") {
+		t.Error("frontmatter should not include headers other than content type")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	m := Metadata{
+		SourceURL:   "https://example.com",
+		Status:      404,
+		FetchMethod: "browser",
+		Timing:      []TimingStep{{"fetch", 1500 * time.Millisecond}},
+	}
+	got, err := JSON(m, "# Hello\n")
+	if err != nil {
+		t.Fatalf("JSON() error: %v", err)
+	}
+
+	for _, want := range []string{
+		`"source": "https://example.com"`,
+		`"status": 404`,
+		`"fetch_method": "browser"`,
+		`"timed_out": false`,
+		`"name": "fetch"`,
+		`"duration_ms": 1500`,
+		`"markdown": "# Hello\n"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("JSON() missing %s\ngot: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "redirects") {
+		t.Error("JSON() should omit empty redirects")
+	}
+}
+
+func TestDemoteHeadings(t *testing.T) {
+	in := "# Title\n\nText with # hash\n\n## Sub\n\n```sh\n# comment\n```\n\n##### Deep\n\n#hashtag\n"
+	want := "### Title\n\nText with # hash\n\n#### Sub\n\n```sh\n# comment\n```\n\n###### Deep\n\n#hashtag\n"
+	if got := DemoteHeadings(in, 2); got != want {
+		t.Errorf("DemoteHeadings() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,334 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	yaml "go.yaml.in/yaml/v3"
+)
+
+// Frontmatter formats.
+const (
+	FrontmatterYAML = "yaml" // between --- lines
+	FrontmatterTOML = "toml" // between +++ lines
+	FrontmatterJSON = "json" // a JSON object followed by a blank line
+)
+
+// ValidateFrontmatterFormat checks that format is one of the frontmatter formats.
+func ValidateFrontmatterFormat(format string) error {
+	switch format {
+	case FrontmatterYAML, FrontmatterTOML, FrontmatterJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid frontmatter format %q (want yaml, toml, or json)", format)
+}
+
+// field is a frontmatter key and its value: a string, int, bool, []string,
+// fields (a nested table), or []fields (a list of tables).
+type field struct {
+	key   string
+	value any
+}
+
+type fields []field
+
+// Frontmatter generates a YAML frontmatter block from metadata.
+// Response headers other than the content type are only included in JSON output.
+func Frontmatter(m Metadata) string {
+	out, _ := FrontmatterAs(m, FrontmatterYAML) // encoding these values can't fail
+	return out
+}
+
+// FrontmatterAs generates a frontmatter block from metadata in the given format.
+func FrontmatterAs(m Metadata, format string) (string, error) {
+	f := frontmatterFields(m)
+	switch format {
+	case FrontmatterYAML:
+		return f.yaml()
+	case FrontmatterTOML:
+		return f.toml()
+	case FrontmatterJSON:
+		return f.json()
+	}
+	return "", ValidateFrontmatterFormat(format)
+}
+
+var metaNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// MetaValue types a user-supplied metadata value: true and false become
+// booleans and JSON-style numbers become numbers, so they are written unquoted
+// in every format. Anything else, including numbers with leading zeros, stays
+// a string.
+func MetaValue(s string) any {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if metaNumberRe.MatchString(s) {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+// frontmatterFields lists the metadata fields in frontmatter order. Extra
+// fields replace standard fields of the same name and follow them otherwise,
+// ahead of the timing.
+func frontmatterFields(m Metadata) fields {
+	var f fields
+	add := func(key string, value any) { f = append(f, field{key, value}) }
+	addString := func(key, value string) {
+		if value != "" {
+			add(key, value)
+		}
+	}
+
+	add("source", m.SourceURL)
+	addString("final_url", m.FinalURL)
+	if m.Status != 0 {
+		add("status", m.Status)
+	}
+
+	p := m.PageMeta
+	addString("title", p.Title)
+	addString("description", p.Description)
+	addString("canonical", p.Canonical)
+	addString("language", p.Language)
+	addString("author", p.Author)
+	addString("published", p.Published)
+	addString("modified", p.Modified)
+	addString("site_name", p.SiteName)
+	if len(p.OpenGraph) > 0 {
+		add("opengraph", sortedFields(p.OpenGraph))
+	}
+	if len(p.Twitter) > 0 {
+		add("twitter", sortedFields(p.Twitter))
+	}
+	if len(p.JSONLDTypes) > 0 {
+		add("jsonld_types", p.JSONLDTypes)
+	}
+
+	addString("content_type", m.Headers["content-type"])
+	if len(m.Redirects) > 0 {
+		var redirects []fields
+		for _, r := range m.Redirects {
+			redirects = append(redirects, fields{{"url", r.URL}, {"status", r.Status}})
+		}
+		add("redirects", redirects)
+	}
+	add("fetch_method", m.FetchMethod)
+	add("timed_out", m.TimedOut)
+	if len(m.Blocked) > 0 {
+		add("blocked_requests", sortedFields(m.Blocked))
+	}
+	if m.Tokenizer != "" {
+		add("tokens", m.Tokens)
+		add("tokenizer", m.Tokenizer)
+	}
+	if m.Truncated {
+		add("truncated", true)
+	}
+
+	for _, e := range sortedFields(m.Extra) {
+		if i := f.index(e.key); i >= 0 {
+			f[i].value = e.value
+		} else {
+			f = append(f, e)
+		}
+	}
+
+	if len(m.Timing) > 0 && f.index("timing") < 0 {
+		var timing fields
+		for _, step := range m.Timing {
+			timing = append(timing, field{step.Name, step.Duration.Round(time.Millisecond).String()})
+		}
+		add("timing", timing)
+	}
+	return f
+}
+
+func (f fields) index(key string) int {
+	for i, fl := range f {
+		if fl.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedFields[V any](m map[string]V) fields {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	f := make(fields, 0, len(keys))
+	for _, k := range keys {
+		f = append(f, field{k, m[k]})
+	}
+	return f
+}
+
+func (f fields) yaml() (string, error) {
+	node, err := yamlNode(f)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("---\n")
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", fmt.Errorf("encoding YAML frontmatter: %w", err)
+	}
+	b.WriteString("---\n\n")
+	return b.String(), nil
+}
+
+// yamlNode builds a YAML node that keeps the fields in order.
+func yamlNode(v any) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case fields:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range v {
+			value, err := yamlNode(f.value)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, value)
+		}
+		return n, nil
+	case []fields:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			value, err := yamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, value)
+		}
+		return n, nil
+	}
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return nil, fmt.Errorf("encoding YAML frontmatter: %w", err)
+	}
+	return n, nil
+}
+
+// toml writes the fields as TOML. Tables must follow all plain keys, so nested
+// fields are written after the others.
+func (f fields) toml() (string, error) {
+	var b strings.Builder
+	b.WriteString("+++\n")
+	if err := tomlFields(&b, f); err != nil {
+		return "", err
+	}
+	for _, fl := range f {
+		switch v := fl.value.(type) {
+		case fields:
+			fmt.Fprintf(&b, "\n[%s]\n", tomlKey(fl.key))
+			if err := tomlFields(&b, v); err != nil {
+				return "", err
+			}
+		case []fields:
+			for _, item := range v {
+				fmt.Fprintf(&b, "\n[[%s]]\n", tomlKey(fl.key))
+				if err := tomlFields(&b, item); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+	b.WriteString("+++\n\n")
+	return b.String(), nil
+}
+
+// tomlFields writes the plain (non-table) fields as key = value lines.
+func tomlFields(b *strings.Builder, f fields) error {
+	for _, fl := range f {
+		switch fl.value.(type) {
+		case fields, []fields:
+			continue
+		}
+		value, err := toml.Marshal(map[string]any{"v": fl.value})
+		if err != nil {
+			return fmt.Errorf("encoding TOML frontmatter: %w", err)
+		}
+		fmt.Fprintf(b, "%s%s", tomlKey(fl.key), bytes.TrimPrefix(value, []byte("v")))
+	}
+	return nil
+}
+
+var tomlBareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(k string) string {
+	if tomlBareKeyRe.MatchString(k) {
+		return k
+	}
+	data, _ := json.Marshal(k) // JSON string escapes are valid in TOML basic strings
+	return string(data)
+}
+
+// json writes the fields as an indented JSON object, keeping them in order.
+func (f fields) json() (string, error) {
+	var b bytes.Buffer
+	if err := jsonValue(&b, f, ""); err != nil {
+		return "", err
+	}
+	b.WriteString("\n\n")
+	return b.String(), nil
+}
+
+func jsonValue(b *bytes.Buffer, v any, indent string) error {
+	switch v := v.(type) {
+	case fields:
+		b.WriteString("{\n")
+		for i, f := range v {
+			key, _ := json.Marshal(f.key)
+			fmt.Fprintf(b, "%s  %s: ", indent, key)
+			if err := jsonValue(b, f.value, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + "}")
+		return nil
+	case []fields:
+		b.WriteString("[\n")
+		for i, item := range v {
+			b.WriteString(indent + "  ")
+			if err := jsonValue(b, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + "]")
+		return nil
+	}
+	data, err := json.MarshalIndent(v, indent, "  ")
+	if err != nil {
+		return fmt.Errorf("encoding JSON frontmatter: %w", err)
+	}
+	b.Write(data)
+	return nil
+}
@@ -0,0 +1,139 @@
+package convert
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	yaml "go.yaml.in/yaml/v3"
+)
+
+// trickyMeta has values that break naive YAML: a fragment, ": ", quotes, and
+// strings YAML would otherwise read as other types.
+var trickyMeta = Metadata{
+	SourceURL:   "https://example.com/a#b: c",
+	Status:      200,
+	FetchMethod: "browser",
+	PageMeta:    PageMeta{Title: `He said "yes": no`, Modified: "2024-06-01", OpenGraph: map[string]string{"type": "article"}},
+	Redirects:   []Redirect{{URL: "http://example.com/", Status: 301}},
+	Tokens:      12,
+	Tokenizer:   "o200k_base",
+	Extra:       map[string]any{"tags": "yes", "title": "Override", "draft": true, "weight": int64(3), "ratio": 0.5},
+	Timing:      []TimingStep{{"fetch", 1234 * time.Millisecond}, {"total", 1300 * time.Millisecond}},
+}
+
+// wantFrontmatter is trickyMeta as it should decode in every format.
+var wantFrontmatter = map[string]any{
+	"source":       "https://example.com/a#b: c",
+	"status":       200,
+	"title":        "Override",
+	"modified":     "2024-06-01",
+	"opengraph":    map[string]any{"type": "article"},
+	"redirects":    []any{map[string]any{"url": "http://example.com/", "status": 301}},
+	"fetch_method": "browser",
+	"timed_out":    false,
+	"tokens":       12,
+	"tokenizer":    "o200k_base",
+	"draft":        true,
+	"ratio":        0.5,
+	"tags":         "yes",
+	"weight":       3,
+	"timing":       map[string]any{"fetch": "1.234s", "total": "1.3s"},
+}
+
+// normalize round-trips v through JSON so decoded values compare equal
+// regardless of the decoder's number and map types.
+func normalize(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	json.Unmarshal(data, &out)
+	data, _ = json.Marshal(out)
+	return string(data)
+}
+
+func TestFrontmatterFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		open   string
+		close  string
+		decode func(string, *map[string]any) error
+	}{
+		{FrontmatterYAML, "---\n", "---\n\n", func(s string, v *map[string]any) error { return yaml.Unmarshal([]byte(s), v) }},
+		{FrontmatterTOML, "+++\n", "+++\n\n", func(s string, v *map[string]any) error { _, err := toml.Decode(s, v); return err }},
+		{FrontmatterJSON, "", "\n\n", func(s string, v *map[string]any) error { return json.Unmarshal([]byte(s), v) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := FrontmatterAs(trickyMeta, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got, tt.open) || !strings.HasSuffix(got, tt.close) {
+				t.Fatalf("delimiters wrong:\n%s", got)
+			}
+			var decoded map[string]any
+			if err := tt.decode(strings.TrimSuffix(strings.TrimPrefix(got, tt.open), tt.close), &decoded); err != nil {
+				t.Fatalf("output doesn't parse: %v\n%s", err, got)
+			}
+			if g, w := normalize(t, decoded), normalize(t, wantFrontmatter); g != w {
+				t.Errorf("decoded:\n%s\nwant:\n%s\noutput:\n%s", g, w, got)
+			}
+		})
+	}
+}
+
+func TestFrontmatterOrder(t *testing.T) {
+	got := Frontmatter(trickyMeta)
+	keys := []string{"source:", "status:", "title:", "modified:", "opengraph:", "redirects:", "fetch_method:", "timed_out:", "tokens:", "tokenizer:", "tags:", "timing:"}
+	last := -1
+	for _, k := range keys {
+		i := strings.Index(got, "\n"+k)
+		if i < last {
+			t.Errorf("%s out of order in:\n%s", k, got)
+		}
+		last = i
+	}
+}
+
+func TestMetaValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"true", true},
+		{"false", false},
+		{"42", int64(42)},
+		{"-7", int64(-7)},
+		{"1.5", 1.5},
+		{"2e3", 2000.0},
+		{"99999999999999999999", 1e20},
+		{"007", "007"},
+		{"yes", "yes"},
+		{"True", "True"},
+		{"1.", "1."},
+		{"NaN", "NaN"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MetaValue(tt.in); got != tt.want {
+			t.Errorf("MetaValue(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFrontmatterFormat(t *testing.T) {
+	for _, f := range []string{"yaml", "toml", "json"} {
+		if err := ValidateFrontmatterFormat(f); err != nil {
+			t.Errorf("ValidateFrontmatterFormat(%q) = %v", f, err)
+		}
+	}
+	if err := ValidateFrontmatterFormat("xml"); err == nil {
+		t.Error("expected error for xml")
+	}
+}
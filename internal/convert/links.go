@@ -0,0 +1,194 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boozedog/webmd/internal/mdblock"
+)
+
+// Link modes for Links.
+const (
+	LinksInline    = "inline"    // [text](url), as converted
+	LinksReference = "reference" // [text][n], with numbered URL definitions at the end
+	LinksText      = "text"      // anchor text only
+	LinksNone      = "none"      // links removed along with their text
+)
+
+// ValidateLinkMode checks that mode is one of the link modes.
+func ValidateLinkMode(mode string) error {
+	switch mode {
+	case LinksInline, LinksReference, LinksText, LinksNone:
+		return nil
+	}
+	return fmt.Errorf("invalid links mode %q (want inline, reference, text, or none)", mode)
+}
+
+// mdLink is an inline link found in markdown.
+type mdLink struct {
+	text string // link text, without brackets
+	dest string // destination URL
+	end  int    // offset just past the closing parenthesis
+}
+
+// Links rewrites the inline links in md according to mode. With dedup, only the
+// first link to each URL is kept in inline mode (later ones become text), and
+// reference mode gives repeated URLs a single number. Images, autolinks, and
+// code are left alone.
+func Links(md, mode string, dedup bool) string {
+	if mode == LinksInline && !dedup {
+		return md
+	}
+
+	var b strings.Builder
+	var refs []string
+	refNum := map[string]int{}
+	seen := map[string]bool{}
+
+	inFence := ""
+	lineStart := true
+	for i := 0; i < len(md); {
+		if lineStart {
+			lineStart = false
+			line := md[i:]
+			if nl := strings.IndexByte(line, '\n'); nl >= 0 {
+				line = line[:nl]
+			}
+			trimmed := strings.TrimLeft(line, " ")
+			if fence := mdblock.Fence(trimmed); inFence != "" || fence != "" {
+				switch {
+				case inFence == "":
+					inFence = fence
+				case mdblock.Closes(trimmed, inFence):
+					inFence = ""
+				}
+				b.WriteString(line)
+				i += len(line)
+				continue
+			}
+		}
+
+		c := md[i]
+		switch {
+		case c == '\n':
+			b.WriteByte(c)
+			i++
+			lineStart = true
+		case c == '\\' && i+1 < len(md):
+			b.WriteString(md[i : i+2])
+			i += 2
+		case c == '`':
+			// Copy code spans verbatim.
+			n := len(md[i:]) - len(strings.TrimLeft(md[i:], "`"))
+			delim := md[i : i+n]
+			if end := strings.Index(md[i+n:], delim); end >= 0 {
+				b.WriteString(md[i : i+n+end+n])
+				i += n + end + n
+			} else {
+				b.WriteString(delim)
+				i += n
+			}
+		case c == '!' && i+1 < len(md) && md[i+1] == '[':
+			// Copy images verbatim.
+			if l, ok := parseLink(md, i+1); ok {
+				b.WriteString(md[i:l.end])
+				i = l.end
+			} else {
+				b.WriteString("![")
+				i += 2
+			}
+		case c == '[':
+			l, ok := parseLink(md, i)
+			if !ok {
+				b.WriteByte(c)
+				i++
+				continue
+			}
+			start := i
+			i = l.end
+			switch {
+			case mode == LinksNone:
+			case mode == LinksText || (mode == LinksInline && seen[l.dest]):
+				b.WriteString(l.text)
+			case mode == LinksReference:
+				n, ok := refNum[l.dest]
+				if !ok || !dedup {
+					refs = append(refs, l.dest)
+					n = len(refs)
+					refNum[l.dest] = n
+				}
+				fmt.Fprintf(&b, "[%s][%d]", l.text, n)
+			default:
+				b.WriteString(md[start:i])
+			}
+			seen[l.dest] = true
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	if len(refs) == 0 {
+		return b.String()
+	}
+	out := strings.TrimRight(b.String(), "\n") + "\n\n"
+	for n, dest := range refs {
+		out += fmt.Sprintf("[%d]: %s\n", n+1, dest)
+	}
+	return out
+}
+
+// parseLink parses an inline link [text](dest "title") starting at md[i] == '['.
+func parseLink(md string, i int) (mdLink, bool) {
+	depth := 0
+	j := i
+	for ; j < len(md); j++ {
+		switch md[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '\n':
+			if j+1 < len(md) && md[j+1] == '\n' {
+				return mdLink{}, false // links don't span paragraphs
+			}
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if j+1 >= len(md) || md[j+1] != '(' {
+		return mdLink{}, false
+	}
+	text := md[i+1 : j]
+
+	start := j + 2
+	depth = 1
+	k := start
+	for ; k < len(md); k++ {
+		switch md[k] {
+		case '\\':
+			k++
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\n':
+			return mdLink{}, false
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if k >= len(md) {
+		return mdLink{}, false
+	}
+	dest := strings.TrimSpace(md[start:k])
+	if sp := strings.IndexAny(dest, " \t"); sp >= 0 {
+		dest = dest[:sp] // drop the title
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	return mdLink{text: text, dest: dest, end: k + 1}, true
+}
@@ -0,0 +1,56 @@
+package convert
+
+import "testing"
+
+func TestLinks(t *testing.T) {
+	md := "See [Docs](https://x.com/docs) and [the docs](https://x.com/docs \"Docs\").\n\n" +
+		"![logo](https://x.com/logo.png) [![badge](https://x.com/b.svg)](https://ci.x.com) `[code](not-a-link)`\n\n" +
+		"```md\n[fenced](https://x.com/f)\n```\n\n" +
+		"[Other \\[1\\]](https://y.com/a_(b)) <https://auto.link>\n"
+
+	tests := []struct {
+		mode  string
+		dedup bool
+		want  string
+	}{
+		{LinksInline, false, md},
+		{LinksInline, true, "See [Docs](https://x.com/docs) and the docs.\n\n" +
+			"![logo](https://x.com/logo.png) [![badge](https://x.com/b.svg)](https://ci.x.com) `[code](not-a-link)`\n\n" +
+			"```md\n[fenced](https://x.com/f)\n```\n\n" +
+			"[Other \\[1\\]](https://y.com/a_(b)) <https://auto.link>\n"},
+		{LinksText, false, "See Docs and the docs.\n\n" +
+			"![logo](https://x.com/logo.png) ![badge](https://x.com/b.svg) `[code](not-a-link)`\n\n" +
+			"```md\n[fenced](https://x.com/f)\n```\n\n" +
+			"Other \\[1\\] <https://auto.link>\n"},
+		{LinksNone, false, "See  and .\n\n" +
+			"![logo](https://x.com/logo.png)  `[code](not-a-link)`\n\n" +
+			"```md\n[fenced](https://x.com/f)\n```\n\n" +
+			" <https://auto.link>\n"},
+		{LinksReference, false, "See [Docs][1] and [the docs][2].\n\n" +
+			"![logo](https://x.com/logo.png) [![badge](https://x.com/b.svg)][3] `[code](not-a-link)`\n\n" +
+			"```md\n[fenced](https://x.com/f)\n```\n\n" +
+			"[Other \\[1\\]][4] <https://auto.link>\n\n" +
+			"[1]: https://x.com/docs\n[2]: https://x.com/docs\n[3]: https://ci.x.com\n[4]: https://y.com/a_(b)\n"},
+		{LinksReference, true, "See [Docs][1] and [the docs][1].\n\n" +
+			"![logo](https://x.com/logo.png) [![badge](https://x.com/b.svg)][2] `[code](not-a-link)`\n\n" +
+			"```md\n[fenced](https://x.com/f)\n```\n\n" +
+			"[Other \\[1\\]][3] <https://auto.link>\n\n" +
+			"[1]: https://x.com/docs\n[2]: https://ci.x.com\n[3]: https://y.com/a_(b)\n"},
+	}
+	for _, tt := range tests {
+		if got := Links(md, tt.mode, tt.dedup); got != tt.want {
+			t.Errorf("Links(%s, dedup=%v) =\n%s\nwant:\n%s", tt.mode, tt.dedup, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLinkMode(t *testing.T) {
+	for _, m := range []string{"inline", "reference", "text", "none"} {
+		if err := ValidateLinkMode(m); err != nil {
+			t.Errorf("ValidateLinkMode(%q) = %v", m, err)
+		}
+	}
+	if err := ValidateLinkMode("footnote"); err == nil {
+		t.Error("ValidateLinkMode(footnote) should fail")
+	}
+}
@@ -0,0 +1,290 @@
+package convert
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/mackee/go-readability"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// PageMeta is metadata a page declares about itself in its <head>: standard
+// meta tags, OpenGraph and Twitter card properties, and JSON-LD.
+type PageMeta struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"`
+	Language    string            `json:"language,omitempty"`
+	Author      string            `json:"author,omitempty"`
+	Published   string            `json:"published,omitempty"`
+	Modified    string            `json:"modified,omitempty"`
+	SiteName    string            `json:"site_name,omitempty"`
+	OpenGraph   map[string]string `json:"opengraph,omitempty"` // og:* properties, without the prefix
+	Twitter     map[string]string `json:"twitter,omitempty"`   // twitter:* names, without the prefix
+	JSONLDTypes []string          `json:"jsonld_types,omitempty"`
+}
+
+// head is what ExtractMeta collects before choosing among fallbacks.
+type head struct {
+	title     string
+	lang      string
+	canonical string
+	meta      map[string]string // name, property, or http-equiv (lowercased) → first content
+	og        map[string]string
+	twitter   map[string]string
+	jsonLD    []any
+}
+
+// Byline is the title and author readability finds in an article.
+type Byline struct {
+	Title  string
+	Author string
+}
+
+// ExtractByline runs readability over HTML for the article's title and byline
+// alone. Readability returns them along with the markdown, so this is only
+// needed when the page isn't converted as an article.
+func ExtractByline(doc string) Byline {
+	article, err := readability.Extract(doc, readability.DefaultOptions())
+	if err != nil {
+		return Byline{}
+	}
+	return Byline{Title: strings.TrimSpace(article.Title), Author: strings.TrimSpace(article.Byline)}
+}
+
+// ExtractMeta reads page metadata from HTML. It must run before StripHidden,
+// which removes the JSON-LD scripts. Relative canonical URLs are resolved
+// against pageURL. A head without a title or author can be completed from the
+// article's byline with Fill.
+func ExtractMeta(doc, pageURL string) PageMeta {
+	h := parseHead(doc)
+	ld := jsonLDFields(h.jsonLD)
+
+	m := PageMeta{
+		Title:       first(h.title, h.og["title"], h.twitter["title"]),
+		Description: first(h.meta["description"], h.og["description"], h.twitter["description"]),
+		Canonical:   resolve(first(h.canonical, h.og["url"]), pageURL),
+		Language:    first(h.lang, h.meta["content-language"], h.og["locale"]),
+		Author:      first(h.meta["author"], h.meta["article:author"], ld.author, h.twitter["creator"]),
+		Published:   first(h.meta["article:published_time"], h.meta["date"], h.meta["pubdate"], ld.published),
+		Modified:    first(h.meta["article:modified_time"], h.og["updated_time"], ld.modified),
+		SiteName:    first(h.og["site_name"], h.meta["application-name"]),
+		JSONLDTypes: ld.types,
+	}
+	if len(h.og) > 0 {
+		m.OpenGraph = h.og
+	}
+	if len(h.twitter) > 0 {
+		m.Twitter = h.twitter
+	}
+	return m
+}
+
+// Complete reports whether the head declared both a title and an author, so
+// that Fill has nothing to add.
+func (m *PageMeta) Complete() bool {
+	return m.Title != "" && m.Author != ""
+}
+
+// Fill uses readability's title and byline where the head declared none.
+func (m *PageMeta) Fill(b Byline) {
+	m.Title = first(m.Title, b.Title)
+	m.Author = first(m.Author, b.Author)
+}
+
+// parseHead tokenizes HTML, collecting head elements until <body> and JSON-LD
+// scripts throughout the document.
+func parseHead(doc string) head {
+	h := head{meta: map[string]string{}, og: map[string]string{}, twitter: map[string]string{}}
+	z := html.NewTokenizer(strings.NewReader(doc))
+	inBody := false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return h
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		t := z.Token()
+		switch t.DataAtom {
+		case atom.Body:
+			inBody = true
+		case atom.Html:
+			h.lang = strings.TrimSpace(attr(t, "lang"))
+		case atom.Script:
+			if !strings.EqualFold(strings.TrimSpace(attr(t, "type")), "application/ld+json") {
+				continue
+			}
+			if z.Next() != html.TextToken {
+				continue
+			}
+			var v any
+			if json.Unmarshal(z.Text(), &v) == nil {
+				h.jsonLD = append(h.jsonLD, v)
+			}
+		case atom.Title:
+			if inBody || h.title != "" {
+				continue
+			}
+			if z.Next() == html.TextToken {
+				h.title = strings.Join(strings.Fields(string(z.Text())), " ")
+			}
+		case atom.Link:
+			if !inBody && h.canonical == "" && hasToken(attr(t, "rel"), "canonical") {
+				h.canonical = strings.TrimSpace(attr(t, "href"))
+			}
+		case atom.Meta:
+			if inBody {
+				continue
+			}
+			content := strings.TrimSpace(attr(t, "content"))
+			if content == "" {
+				continue
+			}
+			for _, key := range []string{attr(t, "property"), attr(t, "name"), attr(t, "http-equiv")} {
+				key = strings.ToLower(strings.TrimSpace(key))
+				switch {
+				case key == "":
+				case strings.HasPrefix(key, "og:"):
+					setFirst(h.og, strings.TrimPrefix(key, "og:"), content)
+				case strings.HasPrefix(key, "twitter:"):
+					setFirst(h.twitter, strings.TrimPrefix(key, "twitter:"), content)
+				default:
+					setFirst(h.meta, key, content)
+				}
+			}
+		}
+	}
+}
+
+// ldFields are the JSON-LD values used as metadata fallbacks.
+type ldFields struct {
+	types                       []string
+	author, published, modified string
+}
+
+// jsonLDFields collects the @type of every top-level JSON-LD item (including
+// those in @graph), and the author and dates of the first item that has them.
+func jsonLDFields(blocks []any) ldFields {
+	var f ldFields
+	seen := map[string]bool{}
+	var visit func(v any)
+	visit = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, item := range v {
+				visit(item)
+			}
+		case map[string]any:
+			for _, t := range ldStrings(v["@type"]) {
+				if !seen[t] {
+					seen[t] = true
+					f.types = append(f.types, t)
+				}
+			}
+			f.author = first(f.author, ldName(v["author"]))
+			f.published = first(f.published, ldString(v["datePublished"]))
+			f.modified = first(f.modified, ldString(v["dateModified"]))
+			if graph, ok := v["@graph"]; ok {
+				visit(graph)
+			}
+		}
+	}
+	for _, b := range blocks {
+		visit(b)
+	}
+	return f
+}
+
+// ldStrings returns a JSON-LD value that is a string or an array of strings.
+func ldStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func ldString(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// ldName returns the name of a JSON-LD person or organization, given as a
+// string, an object with a name, or an array of either (the first is used).
+func ldName(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		return ldString(v["name"])
+	case []any:
+		if len(v) > 0 {
+			return ldName(v[0])
+		}
+	}
+	return ""
+}
+
+func attr(t html.Token, name string) string {
+	for _, a := range t.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether a space-separated attribute value contains tok.
+func hasToken(val, tok string) bool {
+	for _, f := range strings.Fields(val) {
+		if strings.EqualFold(f, tok) {
+			return true
+		}
+	}
+	return false
+}
+
+func setFirst(m map[string]string, key, val string) {
+	if _, ok := m[key]; !ok {
+		m[key] = val
+	}
+}
+
+// first returns the first non-empty value.
+func first(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// resolve makes ref absolute against base, returning ref unchanged if either
+// doesn't parse.
+func resolve(ref, base string) string {
+	if ref == "" {
+		return ""
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
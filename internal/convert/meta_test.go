@@ -0,0 +1,93 @@
+package convert
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMeta(t *testing.T) {
+	page := `<!doctype html><html lang="en-US"><head>
+<title>  Go:
+ The Language </title>
+<meta name="description" content="All about Go.">
+<link rel="alternate canonical" href="/go">
+<meta property="og:site_name" content="Example">
+<meta property="og:title" content="Go">
+<meta property="og:description" content="OG description">
+<meta property="article:published_time" content="2024-05-01T10:00:00Z">
+<meta name="twitter:card" content="summary">
+<script type="application/ld+json">{"@context":"https://schema.org","@graph":[
+  {"@type":"Article","author":[{"@type":"Person","name":"Ann Lee"}],"dateModified":"2024-06-01"},
+  {"@type":["WebPage","Article"]}]}</script>
+</head><body><meta name="description" content="body meta is ignored"><p>Text.</p></body></html>`
+
+	got := ExtractMeta(page, "https://example.com/posts/1")
+	want := PageMeta{
+		Title:       "Go: The Language",
+		Description: "All about Go.",
+		Canonical:   "https://example.com/go",
+		Language:    "en-US",
+		Author:      "Ann Lee",
+		Published:   "2024-05-01T10:00:00Z",
+		Modified:    "2024-06-01",
+		SiteName:    "Example",
+		OpenGraph:   map[string]string{"site_name": "Example", "title": "Go", "description": "OG description"},
+		Twitter:     map[string]string{"card": "summary"},
+		JSONLDTypes: []string{"Article", "WebPage"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractMeta() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestExtractMetaFallbacks(t *testing.T) {
+	page := `<html><head><meta property="og:title" content="OG Title"><meta property="og:url" content="https://example.com/canon"></head><body></body></html>`
+	got := ExtractMeta(page, "https://example.com/")
+	if got.Title != "OG Title" || got.Canonical != "https://example.com/canon" {
+		t.Errorf("ExtractMeta() = %+v", got)
+	}
+
+	if got := ExtractMeta("<p>plain</p>", ""); got.OpenGraph != nil || got.Twitter != nil || got.JSONLDTypes != nil {
+		t.Errorf("ExtractMeta() without metadata = %+v", got)
+	}
+}
+
+func TestPageMetaFill(t *testing.T) {
+	m := PageMeta{Title: "Head Title"}
+	if m.Complete() {
+		t.Fatal("Complete() = true without an author")
+	}
+	m.Fill(Byline{Title: "Article Title", Author: "Ann Lee"})
+	if m.Title != "Head Title" || m.Author != "Ann Lee" {
+		t.Errorf("Fill() = %+v", m)
+	}
+	if !m.Complete() {
+		t.Error("Complete() = false after Fill")
+	}
+}
+
+func TestFrontmatterPageMeta(t *testing.T) {
+	m := Metadata{
+		SourceURL:   "https://example.com",
+		FetchMethod: "browser",
+		PageMeta: PageMeta{
+			Title:       "Go: The Language",
+			Author:      "Ann Lee",
+			Modified:    "2024-06-01",
+			OpenGraph:   map[string]string{"title": "Go", "image": "https://example.com/a.png"},
+			JSONLDTypes: []string{"Article"},
+		},
+	}
+	got := Frontmatter(m)
+	want := "source: https://example.com\n" +
+		"title: 'Go: The Language'\n" +
+		"author: Ann Lee\n" +
+		"modified: \"2024-06-01\"\n" +
+		"opengraph:\n  image: https://example.com/a.png\n  title: Go\n" +
+		"jsonld_types:\n  - Article\n" +
+		"fetch_method: browser\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("Frontmatter() =\n%s\nwant it to contain\n%s", got, want)
+	}
+}
@@ -0,0 +1,181 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfLine is a line of text reassembled from positioned PDF glyphs.
+type pdfLine struct {
+	text string
+	size float64 // largest font size on the line
+	y    float64 // baseline, in points from the bottom of the page
+}
+
+// PDF extracts the text of a PDF document as markdown. Lines set in a font
+// noticeably larger than the body text become headings; other lines are joined
+// into paragraphs, which are split at larger vertical gaps and page breaks.
+func PDF(data []byte) (md string, err error) {
+	defer func() {
+		// The PDF reader panics on some malformed content streams.
+		if r := recover(); r != nil {
+			md, err = "", &ConversionError{Err: fmt.Errorf("reading PDF: %v", r)}
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", &ConversionError{Err: fmt.Errorf("reading PDF: %w", err)}
+	}
+
+	var pages [][]pdfLine
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		pages = append(pages, pdfLines(p.Content().Text))
+	}
+
+	body := bodyFontSize(pages)
+	levels := headingLevels(pages, body)
+
+	var blocks []string
+	for _, lines := range pages {
+		var para []string
+		flush := func() {
+			if len(para) > 0 {
+				blocks = append(blocks, joinPDFLines(para))
+				para = nil
+			}
+		}
+		for i, l := range lines {
+			if level, ok := levels[roundSize(l.size)]; ok && len(l.text) <= 120 {
+				flush()
+				blocks = append(blocks, strings.Repeat("#", level)+" "+l.text)
+				continue
+			}
+			if i > 0 && lines[i-1].y-l.y > 1.8*l.size {
+				flush()
+			}
+			if bullet, ok := strings.CutPrefix(l.text, "•"); ok {
+				flush()
+				blocks = append(blocks, "- "+strings.TrimSpace(bullet))
+				continue
+			}
+			para = append(para, l.text)
+		}
+		flush()
+	}
+
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+// pdfLines groups glyphs into lines by baseline, in content-stream order.
+func pdfLines(texts []pdf.Text) []pdfLine {
+	var lines []pdfLine
+	var b strings.Builder
+	var cur *pdfLine
+	var lastEnd float64
+
+	finish := func() {
+		if cur != nil {
+			if s := strings.Join(strings.Fields(b.String()), " "); s != "" {
+				cur.text = s
+				lines = append(lines, *cur)
+			}
+		}
+		b.Reset()
+		cur = nil
+	}
+
+	for _, t := range texts {
+		if t.S == "\n" || t.S == "" {
+			continue
+		}
+		if cur == nil || math.Abs(t.Y-cur.y) > 0.5*math.Max(cur.size, t.FontSize) {
+			finish()
+			cur = &pdfLine{y: t.Y}
+		} else if t.W > 0 && t.X-lastEnd > 0.2*t.FontSize {
+			// Words are often positioned individually rather than separated by spaces.
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.S)
+		cur.size = math.Max(cur.size, t.FontSize)
+		lastEnd = t.X + t.W
+	}
+	finish()
+	return lines
+}
+
+// bodyFontSize returns the font size covering the most text.
+func bodyFontSize(pages [][]pdfLine) float64 {
+	chars := map[float64]int{}
+	for _, lines := range pages {
+		for _, l := range lines {
+			chars[roundSize(l.size)] += len(l.text)
+		}
+	}
+	var body float64
+	for size, n := range chars {
+		if n > chars[body] || (n == chars[body] && size < body) {
+			body = size
+		}
+	}
+	return body
+}
+
+// headingLevels assigns heading levels to font sizes at least 15% larger than
+// the body text, largest first, up to six levels.
+func headingLevels(pages [][]pdfLine, body float64) map[float64]int {
+	seen := map[float64]bool{}
+	var sizes []float64
+	for _, lines := range pages {
+		for _, l := range lines {
+			s := roundSize(l.size)
+			if s >= body*1.15 && !seen[s] {
+				seen[s] = true
+				sizes = append(sizes, s)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+	levels := map[float64]int{}
+	for i, s := range sizes {
+		levels[s] = min(i+1, 6)
+	}
+	return levels
+}
+
+// roundSize rounds font sizes to half points so minor rendering differences
+// don't split one size into several.
+func roundSize(s float64) float64 {
+	return math.Round(s*2) / 2
+}
+
+// joinPDFLines joins the lines of a paragraph, undoing end-of-line hyphenation.
+func joinPDFLines(lines []string) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if strings.HasSuffix(prev, "-") && len(l) > 0 && l[0] >= 'a' && l[0] <= 'z' {
+				s := b.String()
+				b.Reset()
+				b.WriteString(s[:len(s)-1])
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(l)
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a single-page PDF using Helvetica. Each line is drawn at
+// the given font size and vertical position.
+func buildPDF(lines []struct {
+	size float64
+	y    int
+	text string
+}) []byte {
+	var content strings.Builder
+	for _, l := range lines {
+		fmt.Fprintf(&content, "BT /F1 %g Tf 72 %d Td (%s) Tj ET\n", l.size, l.y, l.text)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func TestPDF(t *testing.T) {
+	data := buildPDF([]struct {
+		size float64
+		y    int
+		text string
+	}{
+		{24, 720, "Annual Report"},
+		{11, 690, "The first paragraph starts here and"},
+		{11, 676, "continues on the next line."},
+		{16, 640, "Results"},
+		{11, 610, "Revenue grew in every re-"},
+		{11, 596, "gion this year."},
+		{11, 560, "A separate paragraph."},
+	})
+
+	got, err := PDF(data)
+	if err != nil {
+		t.Fatalf("PDF() error: %v", err)
+	}
+	want := "# Annual Report\n\n" +
+		"The first paragraph starts here and continues on the next line.\n\n" +
+		"## Results\n\n" +
+		"Revenue grew in every region this year.\n\n" +
+		"A separate paragraph.\n"
+	if got != want {
+		t.Errorf("PDF() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPDFInvalid(t *testing.T) {
+	_, err := PDF([]byte("not a pdf"))
+	if _, ok := err.(*ConversionError); !ok {
+		t.Errorf("PDF() error = %v, want *ConversionError", err)
+	}
+}
@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// PlainText renders markdown as plain text: headings and paragraphs separated
+// by blank lines, list items with bullets or numbers, link and image text
+// without their URLs, code blocks as is, and table cells separated by tabs.
+// Raw HTML is dropped.
+func PlainText(md string) string {
+	src := []byte(md)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(src))
+	return strings.TrimSpace(plainBlocks(doc, src, "\n\n")) + "\n"
+}
+
+// plainBlocks renders the block children of n, separated by sep.
+func plainBlocks(n ast.Node, src []byte, sep string) string {
+	var blocks []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if s := plainBlock(c, src); s != "" {
+			blocks = append(blocks, s)
+		}
+	}
+	return strings.Join(blocks, sep)
+}
+
+func plainBlock(n ast.Node, src []byte) string {
+	switch n := n.(type) {
+	case *ast.Heading, *ast.Paragraph, *ast.TextBlock:
+		return strings.TrimSpace(plainInline(n, src))
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		var b strings.Builder
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			b.Write(seg.Value(src))
+		}
+		return strings.TrimRight(b.String(), "\n")
+	case *ast.Blockquote:
+		return plainBlocks(n, src, "\n\n")
+	case *ast.List:
+		var items []string
+		num := n.Start
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			marker := "• "
+			if n.IsOrdered() {
+				marker = fmt.Sprintf("%d. ", num)
+				num++
+			}
+			sep := "\n"
+			if !n.IsTight {
+				sep = "\n\n"
+			}
+			body := plainBlocks(c, src, sep)
+			indent := strings.Repeat(" ", len([]rune(marker)))
+			items = append(items, marker+strings.ReplaceAll(body, "\n", "\n"+indent))
+		}
+		if n.IsTight {
+			return strings.Join(items, "\n")
+		}
+		return strings.Join(items, "\n\n")
+	case *east.Table:
+		var rows []string
+		for r := n.FirstChild(); r != nil; r = r.NextSibling() {
+			var cells []string
+			for c := r.FirstChild(); c != nil; c = c.NextSibling() {
+				cells = append(cells, strings.TrimSpace(plainInline(c, src)))
+			}
+			rows = append(rows, strings.Join(cells, "\t"))
+		}
+		return strings.Join(rows, "\n")
+	case *ast.HTMLBlock, *ast.ThematicBreak:
+		return ""
+	}
+	return plainBlocks(n, src, "\n\n")
+}
+
+// plainInline returns the text of n's inline content.
+func plainInline(n ast.Node, src []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			for t := c.FirstChild(); t != nil; t = t.NextSibling() {
+				if t, ok := t.(*ast.Text); ok {
+					b.Write(t.Segment.Value(src))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			b.Write(c.Label(src))
+		case *ast.Text:
+			b.Write(util.ResolveNumericReferences(util.ResolveEntityNames(util.UnescapePunctuations(c.Segment.Value(src)))))
+			if c.HardLineBreak() || c.SoftLineBreak() {
+				b.WriteByte('\n')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package convert
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	md := "# Title *with* emphasis\n\n" +
+		"A [link](https://example.com) and ![a chart](chart.png), `a\\*b`, \\* &amp; &#169;.\n" +
+		"Next line <b>raw</b>.\n\n" +
+		"- one\n- two\n  - nested\n\n" +
+		"3. third\n4. fourth\n\n" +
+		"```go\nfmt.Println(\"*\")\n```\n\n" +
+		"| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+		"> quoted\n\n" +
+		"<div>block html</div>\n\n" +
+		"---\n\n" +
+		"<https://auto.example>\n"
+	want := "Title with emphasis\n\n" +
+		"A link and a chart, a\\*b, * & ©.\n" +
+		"Next line raw.\n\n" +
+		"• one\n• two\n  • nested\n\n" +
+		"3. third\n4. fourth\n\n" +
+		"fmt.Println(\"*\")\n\n" +
+		"a\tb\n1\t2\n\n" +
+		"quoted\n\n" +
+		"https://auto.example\n"
+	if got := PlainText(md); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
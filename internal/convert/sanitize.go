@@ -0,0 +1,140 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements are the elements Sanitize keeps, with the attributes each may
+// carry. Other elements are unwrapped, keeping their content, unless they are
+// in droppedElements.
+var allowedElements = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Blockquote: {"cite"}, atom.Pre: nil, atom.Code: nil, atom.Kbd: nil, atom.Samp: nil, atom.Var: nil,
+	atom.Em: nil, atom.Strong: nil, atom.B: nil, atom.I: nil, atom.U: nil, atom.S: nil, atom.Del: nil, atom.Ins: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Small: nil, atom.Mark: nil, atom.Q: {"cite"}, atom.Cite: nil,
+	atom.Abbr: {"title"}, atom.Time: {"datetime"},
+	atom.Table: nil, atom.Caption: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil,
+	atom.Th: {"colspan", "rowspan", "scope"}, atom.Td: {"colspan", "rowspan"},
+	atom.Figure: nil, atom.Figcaption: nil, atom.Picture: nil,
+	atom.Article: nil, atom.Section: nil, atom.Main: nil, atom.Header: nil, atom.Footer: nil, atom.Aside: nil,
+	atom.Details: nil, atom.Summary: nil,
+}
+
+// droppedElements are removed along with their content.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Frame: true, atom.Frameset: true, atom.Object: true, atom.Embed: true, atom.Applet: true,
+	atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+	atom.Svg: true, atom.Math: true, atom.Canvas: true, atom.Audio: true, atom.Video: true,
+	atom.Head: true, atom.Title: true, atom.Meta: true, atom.Link: true, atom.Base: true,
+}
+
+// urlAttrs hold URLs, which must be relative or use a safe scheme, and are
+// dropped when empty.
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Sanitize returns the body of an HTML document reduced to an allowlist of
+// content elements and attributes. Scripts, styles, forms, embedded content,
+// event handlers, and URLs with other schemes (javascript:, data:) are removed.
+func Sanitize(doc string) string {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return ""
+	}
+	body := findElement(root, atom.Body)
+	if body == nil {
+		return ""
+	}
+	var b strings.Builder
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(&b, c)
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func sanitizeNode(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return // comments, doctypes
+	}
+	if droppedElements[n.DataAtom] {
+		return
+	}
+	attrs, ok := allowedElements[n.DataAtom]
+	if ok {
+		b.WriteString("<" + n.Data)
+		for _, a := range n.Attr {
+			if a.Namespace != "" || !contains(attrs, a.Key) || (urlAttrs[a.Key] && !safeURL(a.Val)) {
+				continue
+			}
+			fmt.Fprintf(b, ` %s="%s"`, a.Key, html.EscapeString(a.Val))
+		}
+		b.WriteString(">")
+		if n.DataAtom == atom.Br || n.DataAtom == atom.Hr || n.DataAtom == atom.Img {
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(b, c)
+	}
+	if ok {
+		b.WriteString("</" + n.Data + ">")
+	}
+}
+
+func safeURL(v string) bool {
+	u, err := url.Parse(strings.TrimSpace(v))
+	if err != nil || u.String() == "" {
+		return false
+	}
+	return u.Scheme == "" || safeSchemes[strings.ToLower(u.Scheme)]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// MarkdownHTML renders markdown as sanitized HTML, for output in the HTML
+// format when there is no page HTML (markdown, PDF, text, and feed sources).
+func MarkdownHTML(md string) (string, error) {
+	var buf bytes.Buffer
+	gm := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := gm.Convert([]byte(md), &buf); err != nil {
+		return "", &ConversionError{Err: fmt.Errorf("rendering markdown as HTML: %w", err)}
+	}
+	return Sanitize(buf.String()), nil
+}
@@ -0,0 +1,55 @@
+package convert
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "allowed elements and attributes",
+			in:   `<h1 id="t" class="big">Title</h1><p style="color:red">A <a href="/docs" title="Docs" target="_blank">link</a> <img src="https://example.com/a.png" alt="A" onerror="x()"></p>`,
+			want: `<h1>Title</h1><p>A <a href="/docs" title="Docs">link</a> <img src="https://example.com/a.png" alt="A"></p>` + "\n",
+		},
+		{
+			name: "dropped elements",
+			in:   `<p>Keep</p><script>alert(1)</script><style>p{}</style><form><input name="q"><button>Go</button></form><iframe src="https://ads.example"></iframe><svg><text>icon</text></svg>`,
+			want: "<p>Keep</p>\n",
+		},
+		{
+			name: "unwrapped elements",
+			in:   `<custom-card><font color="red">Text</font> &lt;b&gt;</custom-card>`,
+			want: "Text &lt;b&gt;\n",
+		},
+		{
+			name: "unsafe urls",
+			in:   `<a href="javascript:alert(1)">js</a><a href=" JavaScript:x">js2</a><img src="data:image/png;base64,AAA" alt="d"><a href="mailto:a@example.com">mail</a>`,
+			want: `<a>js</a><a>js2</a><img alt="d"><a href="mailto:a@example.com">mail</a>` + "\n",
+		},
+		{
+			name: "table",
+			in:   `<table><tr><th scope="col" colspan="2">H</th></tr><tr><td rowspan="2" width="10">C</td></tr></table>`,
+			want: `<table><tbody><tr><th scope="col" colspan="2">H</th></tr><tr><td rowspan="2">C</td></tr></tbody></table>` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownHTML(t *testing.T) {
+	got, err := MarkdownHTML("# Title\n\nSome <span>raw</span> text and [a link](javascript:alert(1)).\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<h1>Title</h1>\n<p>Some raw text and <a>a link</a>.</p>\n"
+	if got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
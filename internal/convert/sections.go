@@ -0,0 +1,203 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Section is a heading and the content under it, up to the next heading of the
+// same or a higher level.
+type Section struct {
+	Level int
+	Title string
+	Path  []string // Titles of the enclosing headings and this one, outermost first
+	Start int      // Byte offset of the heading line
+	End   int      // Byte offset just past the section's content
+}
+
+// SectionNotFoundError means no heading matched a section query.
+type SectionNotFoundError struct {
+	Query string
+}
+
+func (e *SectionNotFoundError) Error() string {
+	return fmt.Sprintf("no section matches %q", e.Query)
+}
+
+// Sections parses md and returns its sections in document order. Headings in
+// code blocks and other containers are not sections.
+func Sections(md string) []Section {
+	src := []byte(md)
+	doc := goldmark.New().Parser().Parse(text.NewReader(src))
+
+	var sections []Section
+	var open []int // indexes of sections that enclose the current position
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		lines := h.Lines()
+		if lines.Len() == 0 {
+			continue // an empty heading has no title to match
+		}
+		start := strings.LastIndexByte(md[:lines.At(0).Start], '\n') + 1
+		for len(open) > 0 && sections[open[len(open)-1]].Level >= h.Level {
+			sections[open[len(open)-1]].End = start
+			open = open[:len(open)-1]
+		}
+		var path []string
+		if len(open) > 0 {
+			path = sections[open[len(open)-1]].Path
+		}
+		title := headingText(h, src)
+		sections = append(sections, Section{
+			Level: h.Level,
+			Title: title,
+			Path:  append(path[:len(path):len(path)], title),
+			Start: start,
+		})
+		open = append(open, len(sections)-1)
+	}
+	for _, i := range open {
+		sections[i].End = len(md)
+	}
+	return sections
+}
+
+// headingText returns the plain text of a heading, without markup.
+func headingText(h *ast.Heading, src []byte) string {
+	var b strings.Builder
+	ast.Walk(h, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// ExtractSection returns the first section of md whose heading matches query,
+// including its subsections. The query is a case-insensitive regular
+// expression matched against heading text, or a path of them separated by ">"
+// ("API > Auth"), where each earlier part must match an enclosing heading.
+func ExtractSection(md, query string) (string, error) {
+	matchers, err := sectionQuery(query)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range Sections(md) {
+		if matchPath(s.Path, matchers) {
+			return strings.TrimRight(md[s.Start:s.End], "\n") + "\n", nil
+		}
+	}
+	return "", &SectionNotFoundError{Query: query}
+}
+
+// ValidateSectionQuery checks that query compiles.
+func ValidateSectionQuery(query string) error {
+	_, err := sectionQuery(query)
+	return err
+}
+
+func sectionQuery(query string) ([]*regexp.Regexp, error) {
+	var matchers []*regexp.Regexp
+	for _, part := range strings.Split(query, ">") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid section %q (empty heading in path)", query)
+		}
+		re, err := regexp.Compile("(?i)" + part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid section %q: %w", query, err)
+		}
+		matchers = append(matchers, re)
+	}
+	return matchers, nil
+}
+
+// matchPath reports whether the last matcher matches the last heading in path
+// and the others match enclosing headings, in order.
+func matchPath(path []string, matchers []*regexp.Regexp) bool {
+	last := len(matchers) - 1
+	if !matchers[last].MatchString(path[len(path)-1]) {
+		return false
+	}
+	i := 0
+	for _, title := range path[:len(path)-1] {
+		if i < last && matchers[i].MatchString(title) {
+			i++
+		}
+	}
+	return i == last
+}
+
+// SectionList renders the heading outline of md as a nested markdown list.
+func SectionList(md string) string {
+	var b strings.Builder
+	for _, s := range Sections(md) {
+		fmt.Fprintf(&b, "%s- %s\n", strings.Repeat("  ", len(s.Path)-1), s.Title)
+	}
+	return b.String()
+}
+
+// TOC prepends a table of contents to md, linking each heading by the anchor
+// GitHub-style renderers give it. Markdown without headings is returned as is.
+func TOC(md string) string {
+	sections := Sections(md)
+	if len(sections) == 0 {
+		return md
+	}
+	var b strings.Builder
+	b.WriteString("**Contents**\n\n")
+	slugs := map[string]int{}
+	for _, s := range sections {
+		slug := slugify(s.Title)
+		if n := slugs[slug]; n > 0 {
+			slugs[slug]++
+			slug = fmt.Sprintf("%s-%d", slug, n)
+		} else {
+			slugs[slug] = 1
+		}
+		fmt.Fprintf(&b, "%s- [%s](#%s)\n", strings.Repeat("  ", len(s.Path)-1), escapeLinkText(s.Title), slug)
+	}
+	b.WriteString("\n")
+	return b.String() + md
+}
+
+var slugStripRe = regexp.MustCompile(`[^\p{L}\p{N}\s_-]`)
+
+// slugify returns the anchor GitHub generates for a heading: lowercased, with
+// punctuation removed and spaces turned into hyphens.
+func slugify(title string) string {
+	s := slugStripRe.ReplaceAllString(strings.ToLower(title), "")
+	return strings.ReplaceAll(strings.TrimSpace(s), " ", "-")
+}
+
+func escapeLinkText(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
+
+// Outline renders the heading outline of md as a nested markdown list, with the
+// size of each section, subsections included, as counted by count.
+func Outline(md string, count func(string) int) string {
+	var b strings.Builder
+	for _, s := range Sections(md) {
+		fmt.Fprintf(&b, "%s- %s (%d tokens)\n", strings.Repeat("  ", len(s.Path)-1), s.Title, count(md[s.Start:s.End]))
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package convert
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sectionsDoc = `# Guide
+
+Intro.
+
+## Installation
+
+Run the installer.
+
+### From source
+
+` + "```sh\n# not a heading\nmake\n```" + `
+
+## API
+
+### Auth
+
+Use a token.
+
+### Errors
+
+Codes.
+
+## Auth
+
+Top-level auth notes.
+`
+
+func TestSections(t *testing.T) {
+	var paths [][]string
+	for _, s := range Sections(sectionsDoc) {
+		paths = append(paths, s.Path)
+	}
+	want := [][]string{
+		{"Guide"},
+		{"Guide", "Installation"},
+		{"Guide", "Installation", "From source"},
+		{"Guide", "API"},
+		{"Guide", "API", "Auth"},
+		{"Guide", "API", "Errors"},
+		{"Guide", "Auth"},
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+}
+
+func TestExtractSection(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "subtree",
+			query: "installation",
+			want:  "## Installation\n\nRun the installer.\n\n### From source\n\n```sh\n# not a heading\nmake\n```\n",
+		},
+		{
+			name:  "path",
+			query: "API > Auth",
+			want:  "### Auth\n\nUse a token.\n",
+		},
+		{
+			name:  "regex",
+			query: "^err",
+			want:  "### Errors\n\nCodes.\n",
+		},
+		{
+			name:  "last section",
+			query: "Guide > ^Auth$",
+			want:  "### Auth\n\nUse a token.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractSection(sectionsDoc, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSectionErrors(t *testing.T) {
+	_, err := ExtractSection(sectionsDoc, "Changelog")
+	var notFound *SectionNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("error = %v, want SectionNotFoundError", err)
+	}
+	if _, err := ExtractSection(sectionsDoc, "API >"); err == nil || errors.As(err, &notFound) {
+		t.Errorf("empty path part: error = %v, want invalid query", err)
+	}
+	if err := ValidateSectionQuery("(unclosed"); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestSectionList(t *testing.T) {
+	want := "- Guide\n  - Installation\n    - From source\n  - API\n    - Auth\n    - Errors\n  - Auth\n"
+	if got := SectionList(sectionsDoc); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTOC(t *testing.T) {
+	md := "# Guide\n\n## Install & Run\n\nText.\n\n## Install & Run\n\nAgain.\n\n## [Links] `code`\n"
+	want := "**Contents**\n\n" +
+		"- [Guide](#guide)\n" +
+		"  - [Install & Run](#install--run)\n" +
+		"  - [Install & Run](#install--run-1)\n" +
+		"  - [\\[Links\\] code](#links-code)\n\n" + md
+	if got := TOC(md); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := TOC("No headings.\n"); got != "No headings.\n" {
+		t.Errorf("TOC without headings = %q", got)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	md := "# A\n\none two\n\n## B\n\nthree\n"
+	words := func(s string) int { return len(strings.Fields(s)) }
+	want := "- A (7 tokens)\n  - B (3 tokens)\n"
+	if got := Outline(md, words); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
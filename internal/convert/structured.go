@@ -0,0 +1,145 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/boozedog/webmd/internal/mdblock"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// StructuredData is the schema.org data embedded in a page.
+type StructuredData struct {
+	JSONLD    []json.RawMessage `json:"jsonld,omitempty"`    // JSON-LD blocks as written, minus invalid ones
+	Microdata []*MicrodataItem  `json:"microdata,omitempty"` // Top-level microdata items
+}
+
+// MicrodataItem is an itemscope element in the WHATWG microdata JSON form.
+// Property values are strings or nested items.
+type MicrodataItem struct {
+	Type       []string         `json:"type,omitempty"`
+	ID         string           `json:"id,omitempty"`
+	Properties map[string][]any `json:"properties"`
+}
+
+// ExtractStructuredData returns the JSON-LD and microdata in HTML, or nil if
+// there is none. Like ExtractMeta, it must run before StripHidden. URL
+// property values are resolved against pageURL.
+func ExtractStructuredData(doc, pageURL string) *StructuredData {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return nil
+	}
+	var sd StructuredData
+	var walk func(n *html.Node, item *MicrodataItem)
+	walk = func(n *html.Node, item *MicrodataItem) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.DataAtom == atom.Script && strings.EqualFold(strings.TrimSpace(nodeAttr(c, "type")), "application/ld+json") {
+				if c.FirstChild != nil {
+					if data := []byte(strings.TrimSpace(c.FirstChild.Data)); json.Valid(data) {
+						sd.JSONLD = append(sd.JSONLD, data)
+					}
+				}
+				continue
+			}
+
+			_, scoped := hasAttr(c, "itemscope")
+			props := strings.Fields(nodeAttr(c, "itemprop"))
+			var child *MicrodataItem
+			if scoped {
+				child = &MicrodataItem{
+					Type:       strings.Fields(nodeAttr(c, "itemtype")),
+					ID:         nodeAttr(c, "itemid"),
+					Properties: map[string][]any{},
+				}
+			}
+			switch {
+			case item != nil && len(props) > 0:
+				var v any = propValue(c, pageURL)
+				if child != nil {
+					v = child
+				}
+				for _, p := range props {
+					item.Properties[p] = append(item.Properties[p], v)
+				}
+			case child != nil:
+				sd.Microdata = append(sd.Microdata, child)
+			}
+
+			if child != nil {
+				walk(c, child)
+			} else {
+				walk(c, item)
+			}
+		}
+	}
+	walk(root, nil)
+
+	if sd.JSONLD == nil && sd.Microdata == nil {
+		return nil
+	}
+	return &sd
+}
+
+// propValue returns a microdata property's value: a URL attribute for
+// elements that link to something, a machine-readable attribute where the
+// element has one, and the text content otherwise.
+func propValue(n *html.Node, pageURL string) string {
+	switch n.DataAtom {
+	case atom.Meta:
+		return nodeAttr(n, "content")
+	case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
+		return resolve(nodeAttr(n, "src"), pageURL)
+	case atom.A, atom.Area, atom.Link:
+		return resolve(nodeAttr(n, "href"), pageURL)
+	case atom.Object:
+		return resolve(nodeAttr(n, "data"), pageURL)
+	case atom.Data, atom.Meter:
+		return nodeAttr(n, "value")
+	case atom.Time:
+		if dt, ok := hasAttr(n, "datetime"); ok {
+			return dt
+		}
+	}
+	return strings.Join(strings.Fields(textContent(n)), " ")
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func hasAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func nodeAttr(n *html.Node, name string) string {
+	v, _ := hasAttr(n, name)
+	return v
+}
+
+// StructuredDataAppendix renders structured data as a markdown section with a
+// fenced JSON block, for appending to the page's markdown.
+func StructuredDataAppendix(sd *StructuredData) (string, error) {
+	data, err := json.MarshalIndent(sd, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding structured data: %w", err)
+	}
+	return "## Structured Data\n\n" + mdblock.CodeBlock(string(data), "json"), nil
+}
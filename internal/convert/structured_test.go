@@ -0,0 +1,71 @@
+package convert
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExtractStructuredData(t *testing.T) {
+	page := `<html><head>
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"Recipe","name":"Soup"}</script>
+<script type="application/ld+json">not json</script>
+</head><body>
+<div itemscope itemtype="https://schema.org/Product" itemid="urn:sku:1">
+  <h1 itemprop="name">Kettle <b>Pro</b></h1>
+  <img itemprop="image" src="/kettle.png">
+  <a itemprop="url" href="kettle">Kettle</a>
+  <meta itemprop="sku" content="K-1">
+  <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+    <data itemprop="price" value="39.99">$39.99</data>
+    <time itemprop="validFrom" datetime="2024-01-01">January</time>
+  </div>
+  <span itemprop="color category">Red</span>
+</div>
+<p itemscope><span itemprop="note">Untyped</span></p>
+</body></html>`
+
+	sd := ExtractStructuredData(page, "https://example.com/shop/")
+	if sd == nil {
+		t.Fatal("ExtractStructuredData() = nil")
+	}
+	got, err := json.Marshal(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"jsonld":[{"@context":"https://schema.org","@type":"Recipe","name":"Soup"}],` +
+		`"microdata":[{"type":["https://schema.org/Product"],"id":"urn:sku:1","properties":{` +
+		`"category":["Red"],"color":["Red"],"image":["https://example.com/kettle.png"],"name":["Kettle Pro"],` +
+		`"offers":[{"type":["https://schema.org/Offer"],"properties":{"price":["39.99"],"validFrom":["2024-01-01"]}}],` +
+		`"sku":["K-1"],"url":["https://example.com/shop/kettle"]}},` +
+		`{"properties":{"note":["Untyped"]}}]}`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExtractStructuredDataNone(t *testing.T) {
+	if sd := ExtractStructuredData("<p>Nothing here.</p>", ""); sd != nil {
+		t.Errorf("ExtractStructuredData() = %+v, want nil", sd)
+	}
+}
+
+func TestStructuredDataAppendix(t *testing.T) {
+	got, err := StructuredDataAppendix(&StructuredData{JSONLD: []json.RawMessage{[]byte(`{"@type": "Event"}`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Structured Data\n\n```json\n{\n  \"jsonld\": [\n    {\n      \"@type\": \"Event\"\n    }\n  ]\n}\n```\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A value with a fence inside can't end the code block early.
+	got, err = StructuredDataAppendix(&StructuredData{JSONLD: []json.RawMessage{[]byte("{\"text\": \"x\\n```\\n# Injected\"}")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "## Structured Data\n\n````json\n") || !strings.HasSuffix(got, "\n````\n") {
+		t.Errorf("got:\n%s\nwant a four-backtick fence", got)
+	}
+}
@@ -0,0 +1,156 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/boozedog/webmd/internal/mdblock"
+)
+
+// codeLanguages maps media types to code block languages.
+var codeLanguages = map[string]string{
+	"application/json":       "json",
+	"application/ld+json":    "json",
+	"application/yaml":       "yaml",
+	"application/x-yaml":     "yaml",
+	"text/yaml":              "yaml",
+	"text/x-yaml":            "yaml",
+	"application/xml":        "xml",
+	"text/xml":               "xml",
+	"application/toml":       "toml",
+	"application/javascript": "javascript",
+	"text/javascript":        "javascript",
+	"application/typescript": "typescript",
+	"text/css":               "css",
+	"application/sql":        "sql",
+	"application/x-sh":       "bash",
+	"text/x-shellscript":     "bash",
+	"text/x-python":          "python",
+	"text/x-go":              "go",
+	"text/x-c":               "c",
+	"text/x-java-source":     "java",
+}
+
+// extLanguages maps file extensions to code block languages, for servers that
+// send source files as text/plain.
+var extLanguages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".jsx":   "jsx",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "kotlin",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".sh":    "bash",
+	".bash":  "bash",
+	".zsh":   "zsh",
+	".sql":   "sql",
+	".css":   "css",
+	".scss":  "scss",
+	".lua":   "lua",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".xml":   "xml",
+	".ini":   "ini",
+	".proto": "protobuf",
+	".nix":   "nix",
+}
+
+// Text converts a non-HTML text resource to markdown based on its Content-Type
+// and, for generic types, the file extension of urlPath. CSV and TSV become
+// tables, JSON, YAML, XML and source files become fenced code blocks, and
+// anything else is passed through unchanged.
+func Text(body, contentType, urlPath string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	ext := strings.ToLower(path.Ext(urlPath))
+
+	switch {
+	case mediaType == "text/csv" || ext == ".csv":
+		if table, ok := csvTable(body, ','); ok {
+			return table
+		}
+	case mediaType == "text/tab-separated-values" || ext == ".tsv":
+		if table, ok := csvTable(body, '\t'); ok {
+			return table
+		}
+	}
+
+	lang, ok := codeLanguages[mediaType]
+	switch {
+	case ok:
+	case strings.HasSuffix(mediaType, "+json"):
+		lang = "json"
+	case strings.HasSuffix(mediaType, "+xml"):
+		lang = "xml"
+	case mediaType == "text/plain" || mediaType == "application/octet-stream" || mediaType == "":
+		if lang, ok = extLanguages[ext]; !ok {
+			return body
+		}
+	default:
+		return body
+	}
+
+	if lang == "json" {
+		var buf bytes.Buffer
+		if json.Indent(&buf, []byte(body), "", "  ") == nil {
+			body = buf.String()
+		}
+	}
+	return mdblock.CodeBlock(body, lang)
+}
+
+// csvTable renders delimited data as a markdown table with the first record as
+// the header. It reports false if the data doesn't parse.
+func csvTable(s string, delim rune) (string, bool) {
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil || len(records) == 0 {
+		return "", false
+	}
+
+	cols := 0
+	for _, rec := range records {
+		cols = max(cols, len(rec))
+	}
+
+	var b strings.Builder
+	row := func(rec []string) {
+		b.WriteString("|")
+		for i := range cols {
+			var cell string
+			if i < len(rec) {
+				cell = rec[i]
+			}
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+			cell = strings.Join(strings.Fields(cell), " ")
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+	row(records[0])
+	b.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+	for _, rec := range records[1:] {
+		row(rec)
+	}
+	return b.String(), true
+}
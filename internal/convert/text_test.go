@@ -0,0 +1,72 @@
+package convert
+
+import "testing"
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		path        string
+		want        string
+	}{
+		{
+			name:        "plain text passes through",
+			body:        "just *some* text\n",
+			contentType: "text/plain; charset=utf-8",
+			path:        "/robots.txt",
+			want:        "just *some* text\n",
+		},
+		{
+			name:        "json is indented and fenced",
+			body:        `{"a":[1,2]}`,
+			contentType: "application/json",
+			want:        "```json\n{\n  \"a\": [\n    1,\n    2\n  ]\n}\n```\n",
+		},
+		{
+			name:        "invalid json is fenced as-is",
+			body:        `{"a":`,
+			contentType: "application/problem+json",
+			want:        "```json\n{\"a\":\n```\n",
+		},
+		{
+			name:        "yaml",
+			body:        "a: 1\n",
+			contentType: "application/yaml",
+			want:        "```yaml\na: 1\n```\n",
+		},
+		{
+			name:        "source file by extension",
+			body:        "package main\n",
+			contentType: "text/plain; charset=utf-8",
+			path:        "/boozedog/webmd/main/main.go",
+			want:        "```go\npackage main\n```\n",
+		},
+		{
+			name:        "fence longer than backticks in body",
+			body:        "echo ```\n",
+			contentType: "application/x-sh",
+			want:        "````bash\necho ```\n````\n",
+		},
+		{
+			name:        "csv becomes a table",
+			body:        "name,note\nalice,\"a|b\"\nbob\n",
+			contentType: "text/csv",
+			want:        "| name | note |\n| --- | --- |\n| alice | a\\|b |\n| bob |  |\n",
+		},
+		{
+			name:        "tsv by extension",
+			body:        "a\tb\n1\t2\n",
+			contentType: "text/plain",
+			path:        "/data.tsv",
+			want:        "| a | b |\n| --- | --- |\n| 1 | 2 |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.body, tt.contentType, tt.path); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ResolveURLs regexes — tags whose links end up in the markdown, and their URL attributes.
+var (
+	linkTagRe  = regexp.MustCompile(`(?i)<(?:a|img|area|source|video|audio|iframe)\b[^>]*>`)
+	urlAttrRe  = regexp.MustCompile(`(?i)(\s(?:href|src)\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+	baseHrefRe = regexp.MustCompile(`(?i)<base\b[^>]*\bhref\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+)
+
+// ResolveURLs rewrites relative link and image URLs in HTML to absolute URLs,
+// resolved against the page's <base href> if it has one and pageURL otherwise.
+// Fragment-only links and URLs with a scheme (mailto:, data:, ...) are left as
+// they are. HTML without an absolute http(s) base is returned unchanged.
+func ResolveURLs(doc, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+	if m := baseHrefRe.FindStringSubmatch(doc); m != nil {
+		if href, err := url.Parse(attrValue(m[1])); err == nil {
+			base = base.ResolveReference(href)
+		}
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return doc
+	}
+
+	return linkTagRe.ReplaceAllStringFunc(doc, func(tag string) string {
+		return urlAttrRe.ReplaceAllStringFunc(tag, func(attr string) string {
+			m := urlAttrRe.FindStringSubmatch(attr)
+			ref := strings.TrimSpace(attrValue(m[2]))
+			if ref == "" || strings.HasPrefix(ref, "#") {
+				return attr
+			}
+			u, err := url.Parse(ref)
+			if err != nil || u.Scheme != "" {
+				return attr
+			}
+			return m[1] + `"` + html.EscapeString(base.ResolveReference(u).String()) + `"`
+		})
+	})
+}
+
+// attrValue unquotes and unescapes an HTML attribute value.
+func attrValue(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+		v = v[1 : len(v)-1]
+	}
+	return html.UnescapeString(v)
+}
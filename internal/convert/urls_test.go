@@ -0,0 +1,56 @@
+package convert
+
+import "testing"
+
+func TestResolveURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		pageURL string
+		want    string
+	}{
+		{
+			name:    "relative paths",
+			html:    `<a href="/docs/intro">Intro</a> <img src="../img/a.png" alt="a">`,
+			pageURL: "https://example.com/blog/post/",
+			want:    `<a href="https://example.com/docs/intro">Intro</a> <img src="https://example.com/blog/img/a.png" alt="a">`,
+		},
+		{
+			name:    "base href",
+			html:    `<head><base href="/static/"></head><a href='page.html' class=x>P</a>`,
+			pageURL: "https://example.com/a/b",
+			want:    `<head><base href="/static/"></head><a href="https://example.com/static/page.html" class=x>P</a>`,
+		},
+		{
+			name:    "unquoted and entities",
+			html:    `<a href=next?a=1&amp;b=2>Next</a>`,
+			pageURL: "https://example.com/list",
+			want:    `<a href="https://example.com/next?a=1&amp;b=2">Next</a>`,
+		},
+		{
+			name:    "left alone",
+			html:    `<a href="#top">Top</a><a href="mailto:x@example.com">Mail</a><a href="https://other.org/">O</a><img data-src="lazy.png" src="data:image/png;base64,AA">`,
+			pageURL: "https://example.com/",
+			want:    `<a href="#top">Top</a><a href="mailto:x@example.com">Mail</a><a href="https://other.org/">O</a><img data-src="lazy.png" src="data:image/png;base64,AA">`,
+		},
+		{
+			name:    "absolute base href without page URL",
+			html:    `<base href="https://cdn.example.com/x/"><a href="b.html">B</a>`,
+			pageURL: "file:///tmp/page.html",
+			want:    `<base href="https://cdn.example.com/x/"><a href="https://cdn.example.com/x/b.html">B</a>`,
+		},
+		{
+			name:    "no http base",
+			html:    `<a href="/x">X</a>`,
+			pageURL: "",
+			want:    `<a href="/x">X</a>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveURLs(tt.html, tt.pageURL); got != tt.want {
+				t.Errorf("ResolveURLs() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
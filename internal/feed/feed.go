@@ -0,0 +1,269 @@
+// Package feed parses RSS and Atom feeds.
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Feed is a parsed RSS or Atom feed.
+type Feed struct {
+	Title   string
+	Link    string
+	Entries []Entry
+}
+
+// Entry is a single feed item.
+type Entry struct {
+	Title     string
+	Link      string
+	Published time.Time // zero if the entry has no parseable date
+	Summary   string    // HTML or plain text, as given by the feed
+}
+
+// ErrNotFeed is returned by Parse for XML documents that aren't RSS or Atom.
+var ErrNotFeed = errors.New("not an RSS or Atom feed")
+
+// rss covers RSS 0.9x/2.0 (items inside channel) and RSS 1.0/RDF (items
+// beside it). Elements are matched by local name, so namespaced fields such as
+// content:encoded and dc:date are picked up as well.
+type rss struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Link  []string  `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+	Description string `xml:"description"`
+	Content     string `xml:"encoded"`
+}
+
+type atom struct {
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+// atomText is an Atom text construct. Text and HTML content is escaped and
+// decodes as character data; XHTML content is inline markup.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+	return t.Text
+}
+
+// Parse parses an RSS or Atom document. Relative links are resolved against
+// base, the URL the feed was fetched from.
+func Parse(data []byte, base string) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var f *Feed
+	switch root {
+	case "rss", "RDF":
+		var doc rss
+		if err := decode(data, &doc); err != nil {
+			return nil, err
+		}
+		f = &Feed{Title: doc.Channel.Title}
+		for _, l := range doc.Channel.Link {
+			// Atom self links inside an RSS channel decode as empty strings.
+			if l = strings.TrimSpace(l); l != "" {
+				f.Link = l
+				break
+			}
+		}
+		for _, it := range append(doc.Channel.Items, doc.Items...) {
+			e := Entry{
+				Title:     it.Title,
+				Link:      it.Link,
+				Published: parseDate(firstNonEmpty(it.PubDate, it.Date)),
+				Summary:   firstNonEmpty(it.Content, it.Description),
+			}
+			if e.Link == "" && strings.HasPrefix(it.GUID, "http") {
+				e.Link = it.GUID
+			}
+			f.Entries = append(f.Entries, e)
+		}
+	case "feed":
+		var doc atom
+		if err := decode(data, &doc); err != nil {
+			return nil, err
+		}
+		f = &Feed{Title: doc.Title, Link: atomHref(doc.Links)}
+		for _, it := range doc.Entries {
+			f.Entries = append(f.Entries, Entry{
+				Title:     it.Title,
+				Link:      atomHref(it.Links),
+				Published: parseDate(firstNonEmpty(it.Published, it.Updated)),
+				Summary:   firstNonEmpty(it.Summary.String(), it.Content.String()),
+			})
+		}
+	default:
+		return nil, ErrNotFeed
+	}
+
+	f.Title = strings.TrimSpace(f.Title)
+	f.Link = resolve(base, f.Link)
+	for i := range f.Entries {
+		e := &f.Entries[i]
+		e.Title = strings.TrimSpace(e.Title)
+		e.Link = resolve(base, strings.TrimSpace(e.Link))
+		e.Summary = strings.TrimSpace(e.Summary)
+	}
+	return f, nil
+}
+
+// Since returns the entries published at or after t. Entries without a date
+// are left out, since they can't be shown to be recent.
+func (f *Feed) Since(t time.Time) []Entry {
+	var entries []Entry
+	for _, e := range f.Entries {
+		if !e.Published.IsZero() && !e.Published.Before(t) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ParseSince parses a --since value relative to now: a duration such as 36h or
+// 7d, or a date as 2006-01-02 or RFC 3339.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q (want a duration like 7d or 36h, or a date like 2006-01-02)", s)
+}
+
+func decode(data []byte, v any) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.CharsetReader = charset.NewReaderLabel
+	d.Strict = false
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("parsing feed: %w", err)
+	}
+	return nil
+}
+
+// rootElement returns the local name of the document's root element.
+func rootElement(data []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.CharsetReader = charset.NewReaderLabel
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", ErrNotFeed
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			return el.Name.Local, nil
+		}
+	}
+}
+
+// atomHref returns the alternate link, which is also the default when rel is omitted.
+func atomHref(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// dateLayouts are the date formats seen in the wild, most common first.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+  <title>Vendor Blog</title>
+  <atom:link href="https://example.com/feed.xml" rel="self"/>
+  <link>https://example.com/</link>
+  <item>
+    <title>Release 2.0</title>
+    <link>/blog/release-2</link>
+    <pubDate>Tue, 14 Oct 2026 09:30:00 +0000</pubDate>
+    <description>Short summary</description>
+    <content:encoded><![CDATA[<p>Full <b>content</b></p>]]></content:encoded>
+  </item>
+  <item>
+    <title>Old post</title>
+    <guid>https://example.com/blog/old</guid>
+    <pubDate>Mon, 5 Jan 2026 08:00:00 GMT</pubDate>
+    <description>&lt;p&gt;Escaped HTML&lt;/p&gt;</description>
+  </item>
+</channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Changelog</title>
+  <link href="https://example.org/" />
+  <link rel="self" href="https://example.org/atom.xml" />
+  <entry>
+    <title>Fixed a bug</title>
+    <link rel="alternate" href="https://example.org/changes/1" />
+    <updated>2026-10-10T12:00:00Z</updated>
+    <summary type="html">&lt;p&gt;Details&lt;/p&gt;</summary>
+  </entry>
+  <entry>
+    <title>Inline markup</title>
+    <link href="https://example.org/changes/2" />
+    <published>2026-10-12T12:00:00+02:00</published>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hello</p></div></content>
+  </entry>
+</feed>`
+
+func TestParseRSS(t *testing.T) {
+	f, err := Parse([]byte(rssFeed), "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if f.Title != "Vendor Blog" || f.Link != "https://example.com/" {
+		t.Errorf("feed = %q %q", f.Title, f.Link)
+	}
+	if len(f.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(f.Entries))
+	}
+
+	e := f.Entries[0]
+	if e.Link != "https://example.com/blog/release-2" {
+		t.Errorf("relative link resolved to %q", e.Link)
+	}
+	if !e.Published.Equal(time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Published = %v", e.Published)
+	}
+	if e.Summary != "<p>Full <b>content</b></p>" {
+		t.Errorf("content:encoded should win over description, got %q", e.Summary)
+	}
+
+	e = f.Entries[1]
+	if e.Link != "https://example.com/blog/old" {
+		t.Errorf("guid fallback link = %q", e.Link)
+	}
+	if e.Summary != "<p>Escaped HTML</p>" {
+		t.Errorf("Summary = %q", e.Summary)
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	f, err := Parse([]byte(atomFeed), "https://example.org/atom.xml")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if f.Title != "Changelog" || f.Link != "https://example.org/" {
+		t.Errorf("feed = %q %q", f.Title, f.Link)
+	}
+	if len(f.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(f.Entries))
+	}
+	if got := f.Entries[0]; got.Link != "https://example.org/changes/1" || got.Summary != "<p>Details</p>" || got.Published.IsZero() {
+		t.Errorf("entry 0 = %+v", got)
+	}
+	if got := f.Entries[1].Summary; got != `<div xmlns="http://www.w3.org/1999/xhtml"><p>Hello</p></div>` {
+		t.Errorf("xhtml content = %q", got)
+	}
+}
+
+func TestParseNotFeed(t *testing.T) {
+	for _, doc := range []string{`<html><body>hi</body></html>`, `{"a":1}`, "plain text"} {
+		if _, err := Parse([]byte(doc), ""); !errors.Is(err, ErrNotFeed) {
+			t.Errorf("Parse(%q) error = %v, want ErrNotFeed", doc, err)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	f, err := Parse([]byte(rssFeed), "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := f.Since(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if len(got) != 1 || got[0].Title != "Release 2.0" {
+		t.Errorf("Since() = %+v", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"7d", time.Date(2026, 10, 11, 12, 0, 0, 0, time.UTC)},
+		{"36h", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-10-01T08:00:00Z", time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "-3d", "10/01/2026"} {
+		if _, err := ParseSince(bad, now); err == nil {
+			t.Errorf("ParseSince(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package fetch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// resourceTypes maps the resource type names accepted by Options.BlockResources
+// to their CDP equivalents.
+var resourceTypes = map[string]proto.NetworkResourceType{
+	"image":      proto.NetworkResourceTypeImage,
+	"media":      proto.NetworkResourceTypeMedia,
+	"font":       proto.NetworkResourceTypeFont,
+	"stylesheet": proto.NetworkResourceTypeStylesheet,
+}
+
+// TrackerDomains is the built-in blocklist of common ad and analytics domains.
+// Subdomains are blocked too.
+var TrackerDomains = []string{
+	"doubleclick.net",
+	"googlesyndication.com",
+	"googleadservices.com",
+	"google-analytics.com",
+	"googletagmanager.com",
+	"googletagservices.com",
+	"adservice.google.com",
+	"amazon-adsystem.com",
+	"adnxs.com",
+	"criteo.com",
+	"taboola.com",
+	"outbrain.com",
+	"scorecardresearch.com",
+	"quantserve.com",
+	"connect.facebook.net",
+	"analytics.twitter.com",
+	"ads.linkedin.com",
+	"bat.bing.com",
+	"hotjar.com",
+	"mixpanel.com",
+	"segment.io",
+	"segment.com",
+	"fullstory.com",
+	"newrelic.com",
+	"nr-data.net",
+	"chartbeat.com",
+	"clarity.ms",
+}
+
+// ValidateResourceTypes checks that every name is a blockable resource type.
+func ValidateResourceTypes(names []string) error {
+	for _, name := range names {
+		if _, ok := resourceTypes[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("unknown resource type %q (want image, media, font, or stylesheet)", name)
+		}
+	}
+	return nil
+}
+
+// LoadBlocklist reads a domain blocklist file with one domain per line.
+// Blank lines and # comments are ignored, and hosts-file entries such as
+// "0.0.0.0 ads.example.com" are accepted.
+func LoadBlocklist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading blocklist: %w", err)
+	}
+	defer f.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		domains = append(domains, strings.ToLower(fields[len(fields)-1]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading blocklist: %w", err)
+	}
+	return domains, nil
+}
+
+// domainBlocked reports whether host is one of domains or a subdomain of one.
+func domainBlocked(host string, domains []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
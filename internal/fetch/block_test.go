@@ -0,0 +1,58 @@
+package fetch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDomainBlocked(t *testing.T) {
+	domains := []string{"doubleclick.net", "example.com"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"doubleclick.net", true},
+		{"ad.doubleclick.net", true},
+		{"AD.DoubleClick.NET", true},
+		{"example.com.", true},
+		{"notdoubleclick.net", false},
+		{"doubleclick.net.evil.com", false},
+		{"example.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := domainBlocked(tt.host, domains); got != tt.want {
+				t.Errorf("domainBlocked(%q) = %t, want %t", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	content := "# ad servers\nads.example.com\n\n0.0.0.0 Tracker.Example.net # hosts format\n  metrics.example.org  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatalf("LoadBlocklist() error: %v", err)
+	}
+	want := []string{"ads.example.com", "tracker.example.net", "metrics.example.org"}
+	if !slices.Equal(got, want) {
+		t.Errorf("LoadBlocklist() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateResourceTypes(t *testing.T) {
+	if err := ValidateResourceTypes([]string{"image", "Font", "stylesheet", "media"}); err != nil {
+		t.Errorf("ValidateResourceTypes() unexpected error: %v", err)
+	}
+	if err := ValidateResourceTypes([]string{"script"}); err == nil {
+		t.Error("ValidateResourceTypes() should reject script")
+	}
+}
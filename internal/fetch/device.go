@@ -0,0 +1,107 @@
+package fetch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Device describes an emulated screen and browser.
+type Device struct {
+	Viewport
+	Scale     float64 // Device pixel ratio
+	Mobile    bool
+	Touch     bool
+	UserAgent string // Empty keeps the browser's user agent
+}
+
+// Devices are the named presets accepted by ParseDevice.
+var Devices = map[string]Device{
+	// iPhone 14 Pro Max, also used by --mobile.
+	"iphone": {
+		Viewport:  Viewport{430, 932},
+		Scale:     3,
+		Mobile:    true,
+		Touch:     true,
+		UserAgent: mobileUserAgent,
+	},
+	"iphone-se": {
+		Viewport:  Viewport{375, 667},
+		Scale:     2,
+		Mobile:    true,
+		Touch:     true,
+		UserAgent: mobileUserAgent,
+	},
+	// Pixel 7.
+	"pixel": {
+		Viewport:  Viewport{412, 915},
+		Scale:     2.625,
+		Mobile:    true,
+		Touch:     true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+	},
+	// Galaxy S23.
+	"galaxy": {
+		Viewport:  Viewport{360, 780},
+		Scale:     3,
+		Mobile:    true,
+		Touch:     true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+	},
+	// iPad Air.
+	"ipad": {
+		Viewport:  Viewport{820, 1180},
+		Scale:     2,
+		Mobile:    true,
+		Touch:     true,
+		UserAgent: tabletUserAgent,
+	},
+	// iPad Pro 12.9".
+	"ipad-pro": {
+		Viewport:  Viewport{1024, 1366},
+		Scale:     2,
+		Mobile:    true,
+		Touch:     true,
+		UserAgent: tabletUserAgent,
+	},
+	"laptop": {
+		Viewport: Viewport{1366, 768},
+		Scale:    1,
+	},
+	"desktop-1080p": {
+		Viewport: Viewport{1920, 1080},
+		Scale:    1,
+	},
+	"desktop-1440p": {
+		Viewport: Viewport{2560, 1440},
+		Scale:    1,
+	},
+}
+
+// iOS Safari user agents for the iPhone and iPad presets.
+const (
+	mobileUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	tabletUserAgent = "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+)
+
+// DeviceNames returns the names of the device presets in sorted order.
+func DeviceNames() []string {
+	names := make([]string, 0, len(Devices))
+	for name := range Devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseDevice resolves a device preset name, or a custom WIDTHxHEIGHT size which
+// is emulated as a desktop screen.
+func ParseDevice(s string) (*Device, error) {
+	if d, ok := Devices[strings.ToLower(s)]; ok {
+		return &d, nil
+	}
+	if v, err := ParseViewport(s); err == nil {
+		return &Device{Viewport: v, Scale: 1}, nil
+	}
+	return nil, fmt.Errorf("unknown device %q (want WIDTHxHEIGHT or one of: %s)", s, strings.Join(DeviceNames(), ", "))
+}
@@ -0,0 +1,46 @@
+package fetch
+
+import "testing"
+
+func TestParseDevice(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Device
+		wantErr bool
+	}{
+		{input: "iphone", want: Devices["iphone"]},
+		{input: "Pixel", want: Devices["pixel"]},
+		{input: "desktop-1080p", want: Device{Viewport: Viewport{1920, 1080}, Scale: 1}},
+		{input: "1280x720", want: Device{Viewport: Viewport{1280, 720}, Scale: 1}},
+		{input: "nokia-3310", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDevice(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDevice(%q) = %+v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDevice(%q) error: %v", tt.input, err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseDevice(%q) = %+v, want %+v", tt.input, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevicePresetsMobileHaveUserAgent(t *testing.T) {
+	for name, d := range Devices {
+		if d.Mobile && d.UserAgent == "" {
+			t.Errorf("mobile preset %q has no user agent", name)
+		}
+		if d.Width <= 0 || d.Height <= 0 || d.Scale <= 0 {
+			t.Errorf("preset %q has invalid metrics: %+v", name, d)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package fetch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones are checked where the system has no zoneinfo too
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"golang.org/x/text/language"
+)
+
+// Viewport is a page size in CSS pixels.
+type Viewport struct {
+	Width  int
+	Height int
+}
+
+// ValidateLocale checks an Accept-Language value, whose first language also
+// sets the page's locale, and an IANA timezone name. Empty values are valid.
+// Chrome would otherwise only reject them once the page is open.
+func ValidateLocale(lang, timezone string) error {
+	if lang != "" {
+		if _, _, err := language.ParseAcceptLanguage(lang); err != nil {
+			return fmt.Errorf("invalid lang %q (want a language tag such as de-DE, or an Accept-Language list)", lang)
+		}
+		if _, err := language.Parse(locale(lang)); err != nil {
+			return fmt.Errorf("invalid lang %q (want a language tag such as de-DE, or an Accept-Language list)", lang)
+		}
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+			return fmt.Errorf("invalid timezone %q (want an IANA name such as Europe/Berlin)", timezone)
+		}
+	}
+	return nil
+}
+
+// locale returns the primary language tag of an Accept-Language value.
+func locale(lang string) string {
+	tag, _, _ := strings.Cut(lang, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	return strings.TrimSpace(tag)
+}
+
+// ParseViewport parses a size of the form WIDTHxHEIGHT, e.g. "1920x1080".
+func ParseViewport(s string) (Viewport, error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return Viewport{}, fmt.Errorf("invalid viewport %q (want WIDTHxHEIGHT)", s)
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return Viewport{}, fmt.Errorf("invalid viewport %q (want WIDTHxHEIGHT)", s)
+	}
+	return Viewport{Width: width, Height: height}, nil
+}
+
+// Geolocation is an emulated device position.
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64 // meters
+}
+
+// ParseGeolocation parses a position of the form "LAT,LON" or "LAT,LON,ACCURACY".
+// Accuracy defaults to 100 meters.
+func ParseGeolocation(s string) (*Geolocation, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid geolocation %q (want LAT,LON[,ACCURACY])", s)
+	}
+	vals := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid geolocation %q (want LAT,LON[,ACCURACY])", s)
+		}
+		vals[i] = v
+	}
+	if vals[0] < -90 || vals[0] > 90 || vals[1] < -180 || vals[1] > 180 {
+		return nil, fmt.Errorf("invalid geolocation %q: latitude or longitude out of range", s)
+	}
+	g := &Geolocation{Latitude: vals[0], Longitude: vals[1], Accuracy: 100}
+	if len(vals) == 3 {
+		g.Accuracy = vals[2]
+	}
+	return g, nil
+}
+
+// emulate applies the device, locale, timezone, and geolocation settings from
+// opts to page before navigation.
+func emulate(page *rod.Page, opts Options) error {
+	userAgent := opts.UserAgent
+	if d := opts.Device; d != nil {
+		err := proto.EmulationSetDeviceMetricsOverride{
+			Width:             d.Width,
+			Height:            d.Height,
+			DeviceScaleFactor: d.Scale,
+			Mobile:            d.Mobile,
+		}.Call(page)
+		if err != nil {
+			return fmt.Errorf("setting viewport: %w", err)
+		}
+		touch := proto.EmulationSetTouchEmulationEnabled{Enabled: d.Touch}
+		if d.Touch {
+			maxPoints := 5
+			touch.MaxTouchPoints = &maxPoints
+		}
+		if err := touch.Call(page); err != nil {
+			return fmt.Errorf("setting touch emulation: %w", err)
+		}
+		if userAgent == "" {
+			userAgent = d.UserAgent
+		}
+	}
+
+	if userAgent != "" || opts.Lang != "" {
+		if userAgent == "" {
+			// The override needs a user agent; keep the page's current one.
+			res, err := page.Eval(`() => navigator.userAgent`)
+			if err != nil {
+				return fmt.Errorf("reading user agent: %w", err)
+			}
+			userAgent = res.Value.Str()
+		}
+		err := proto.NetworkSetUserAgentOverride{
+			UserAgent:      userAgent,
+			AcceptLanguage: opts.Lang,
+		}.Call(page)
+		if err != nil {
+			return fmt.Errorf("setting user agent: %w", err)
+		}
+	}
+
+	if opts.Lang != "" {
+		// Intl and date formatting follow the primary language tag.
+		err := proto.EmulationSetLocaleOverride{Locale: locale(opts.Lang)}.Call(page)
+		if err != nil {
+			return fmt.Errorf("setting locale: %w", err)
+		}
+	}
+
+	if opts.Timezone != "" {
+		err := proto.EmulationSetTimezoneOverride{TimezoneID: opts.Timezone}.Call(page)
+		if err != nil {
+			return fmt.Errorf("setting timezone %q: %w", opts.Timezone, err)
+		}
+	}
+
+	if g := opts.Geolocation; g != nil {
+		err := proto.BrowserGrantPermissions{
+			Permissions:      []proto.BrowserPermissionType{proto.BrowserPermissionTypeGeolocation},
+			BrowserContextID: page.Browser().BrowserContextID,
+		}.Call(page.Browser())
+		if err != nil {
+			return fmt.Errorf("granting geolocation permission: %w", err)
+		}
+		err = proto.EmulationSetGeolocationOverride{
+			Latitude:  &g.Latitude,
+			Longitude: &g.Longitude,
+			Accuracy:  &g.Accuracy,
+		}.Call(page)
+		if err != nil {
+			return fmt.Errorf("setting geolocation: %w", err)
+		}
+	}
+
+	return nil
+}
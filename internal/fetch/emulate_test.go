@@ -0,0 +1,89 @@
+package fetch
+
+import "testing"
+
+func TestParseViewport(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Viewport
+		wantErr bool
+	}{
+		{input: "1920x1080", want: Viewport{1920, 1080}},
+		{input: "390X844", want: Viewport{390, 844}},
+		{input: "1920", wantErr: true},
+		{input: "0x800", wantErr: true},
+		{input: "axb", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseViewport(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseViewport(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseViewport(%q) error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseViewport(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	tests := []struct {
+		lang, timezone string
+		wantErr        bool
+	}{
+		{"", "", false},
+		{"de-DE", "Europe/Berlin", false},
+		{"de-DE,de;q=0.9,en;q=0.5", "UTC", false},
+		{"not a language", "", true},
+		{"*", "", true},
+		{"", "Mars/Olympus", true},
+		{"", "Local", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateLocale(tt.lang, tt.timezone); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateLocale(%q, %q) error = %v, wantErr %v", tt.lang, tt.timezone, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseGeolocation(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Geolocation
+		wantErr bool
+	}{
+		{input: "52.52,13.405", want: Geolocation{52.52, 13.405, 100}},
+		{input: "-33.87, 151.21, 25", want: Geolocation{-33.87, 151.21, 25}},
+		{input: "91,0", wantErr: true},
+		{input: "0,181", wantErr: true},
+		{input: "52.52", wantErr: true},
+		{input: "north,east", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseGeolocation(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseGeolocation(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeolocation(%q) error: %v", tt.input, err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseGeolocation(%q) = %v, want %v", tt.input, *got, tt.want)
+			}
+		})
+	}
+}
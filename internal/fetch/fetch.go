@@ -1,14 +1,18 @@
 package fetch
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/boozedog/webmd/internal/browser"
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
 )
@@ -18,94 +22,256 @@ type Options struct {
 	Timeout   time.Duration
 	Wait      time.Duration
 	UserAgent string
-	Mobile    bool
-}
+	Proxy     string // http, https, or socks5 URL; http and https may carry credentials
+
+	Device      *Device      // Emulated device; nil keeps the browser default
+	Lang        string       // Accept-Language value, e.g. "de-DE,de;q=0.9"; also sets the locale
+	Timezone    string       // IANA timezone ID, e.g. "Europe/Berlin"
+	Geolocation *Geolocation // Emulated position; geolocation permission is granted when set
+
+	BlockResources []string // Resource types to block: image, media, font, stylesheet
+	BlockDomains   []string // Domains (and their subdomains) whose requests are blocked
+
+	// Persistent uses the browser's own context for the page instead of a fresh
+	// incognito context, so cookies and storage persist across fetches.
+	Persistent bool
 
-// iPhone 14 Pro Max dimensions and UA for mobile emulation.
-const mobileUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	Screenshot *Screenshot // Image of the rendered page to capture; nil for none
+}
 
 // Result holds the fetched content and metadata about the fetch.
 type Result struct {
 	HTML     string
 	Markdown string // Set when the server provided markdown directly.
+	PDF      []byte // Set when the response was a PDF document.
+	Text     string // Set when the response was a non-HTML text resource (plain text, JSON, CSV, source code, ...).
 	TimedOut bool
+	Blocked  map[string]int // Blocked request counts by resource type, or "domain" for blocklist hits.
+	Response Response
+
+	Screenshot []byte // Set when opts.Screenshot asked for one and the page was rendered in a browser.
+
+	// ScreenshotErr reports why the screenshot couldn't be captured. It
+	// doesn't fail the page: the HTML is still returned.
+	ScreenshotErr error
+}
+
+// NavigationError reports that the browser failed to load a page.
+type NavigationError struct {
+	URL string
+	Err error
+}
+
+func (e *NavigationError) Error() string { return e.Err.Error() }
+func (e *NavigationError) Unwrap() error { return e.Err }
+
+// TimeoutError reports that a page did not finish loading within the timeout.
+// Partial is set when some content was still captured.
+type TimeoutError struct {
+	URL     string
+	Timeout time.Duration
+	Partial bool
 }
 
-// Markdown attempts a lightweight HTTP GET with Accept: text/markdown.
-// Returns the markdown body if the server responds with text/markdown,
-// or empty string if not supported.
-func Markdown(url string, timeout time.Duration) string {
-	client := &http.Client{Timeout: timeout}
-	req, err := http.NewRequest("GET", url, nil)
+func (e *TimeoutError) Error() string {
+	if e.Partial {
+		return fmt.Sprintf("%s timed out after %s; content may be incomplete", e.URL, e.Timeout)
+	}
+	return fmt.Sprintf("%s timed out after %s", e.URL, e.Timeout)
+}
+
+// MaxBodySize limits the size of responses read without a browser.
+const MaxBodySize = 32 << 20
+
+// TooLargeError reports a response body larger than MaxBodySize.
+type TooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s: response body exceeds %d bytes", e.URL, e.Limit)
+}
+
+// Direct attempts a lightweight HTTP GET that prefers text/markdown.
+// Returns a Result if the response can be converted without a browser —
+// markdown provided by the server, a PDF (detected by Content-Type or magic
+// bytes), or another non-HTML text resource — and nil otherwise. The only
+// error is a *TooLargeError for such a response over MaxBodySize.
+func Direct(opts Options) (*Result, error) {
+	var redirects []Redirect
+	client, err := httpClient(opts)
+	if err != nil {
+		return nil, nil
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		redirects = append(redirects, Redirect{URL: via[len(via)-1].URL.String(), Status: req.Response.StatusCode})
+		return nil
+	}
+	req, err := http.NewRequest("GET", opts.URL, nil)
 	if err != nil {
-		return ""
+		return nil, nil
+	}
+	req.Header.Set("Accept", "text/markdown, */*;q=0.8")
+	if opts.Lang != "" {
+		req.Header.Set("Accept-Language", opts.Lang)
 	}
-	req.Header.Set("Accept", "text/markdown")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return ""
+		return nil, nil
 	}
 	defer resp.Body.Close()
 
+	var result Result
 	ct := resp.Header.Get("Content-Type")
-	if !strings.HasPrefix(ct, "text/markdown") {
-		return ""
+	body := bufio.NewReader(resp.Body)
+	var text *string // where to store a text body; PDFs are kept as bytes
+	switch {
+	case strings.HasPrefix(ct, "text/markdown"):
+		text = &result.Markdown
+	case isPDF(ct, body):
+	case isText(ct, body):
+		text = &result.Text
+	default:
+		return nil, nil
 	}
-
-	body, err := io.ReadAll(resp.Body)
+	b, err := io.ReadAll(io.LimitReader(body, MaxBodySize+1))
 	if err != nil {
-		return ""
+		return nil, nil
+	}
+	if len(b) > MaxBodySize {
+		return nil, &TooLargeError{URL: opts.URL, Limit: MaxBodySize}
+	}
+	if text != nil {
+		*text = string(b)
+	} else {
+		result.PDF = b
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		headers[strings.ToLower(k)] = resp.Header.Get(k)
+	}
+	result.Response = Response{
+		FinalURL:  resp.Request.URL.String(),
+		Status:    resp.StatusCode,
+		Headers:   headers,
+		Redirects: redirects,
 	}
-	return string(body)
+	return &result, nil
+}
+
+// textTypes are non-text/* media types that are read as text rather than rendered.
+var textTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"application/toml":       true,
+	"application/javascript": true,
+	"application/typescript": true,
+	"application/sql":        true,
+	"application/x-sh":       true,
+}
+
+// isText reports whether a response is text that doesn't need a browser: any
+// text/* type except HTML, the types in textTypes, and +json/+xml types other
+// than XHTML. Generic or missing types are sniffed, since many servers send
+// source files as application/octet-stream.
+func isText(contentType string, body *bufio.Reader) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		head, _ := body.Peek(512)
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+		if mediaType != "text/plain" {
+			return false
+		}
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return false
+	case strings.HasPrefix(mediaType, "text/"), textTypes[mediaType]:
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// isPDF reports whether a response is a PDF, by Content-Type or, for servers
+// that send a generic type, by the %PDF- signature at the start of the body.
+func isPDF(contentType string, body *bufio.Reader) bool {
+	if strings.HasPrefix(contentType, "application/pdf") {
+		return true
+	}
+	magic, _ := body.Peek(5)
+	return string(magic) == "%PDF-"
+}
+
+// httpClient returns an HTTP client honoring the timeout and proxy in opts.
+func httpClient(opts Options) (*http.Client, error) {
+	client := &http.Client{Timeout: opts.Timeout}
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy: %w", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		client.Transport = transport
+	}
+	return client, nil
 }
 
 // Page connects to a browser via controlURL, navigates to the target URL,
-// and returns the fully rendered HTML. The browser connection is closed when done.
+// and returns the fully rendered HTML. The browser connection is closed when done,
+// but the browser itself is left running so remote browsers can be shared.
 // If the page times out waiting for the DOM to stabilize, it returns whatever
 // HTML is available along with TimedOut=true.
 func Page(controlURL string, opts Options) (*Result, error) {
-	b := rod.New().ControlURL(controlURL)
-	if err := b.Connect(); err != nil {
-		return nil, fmt.Errorf("connecting to browser: %w", err)
+	conn, err := browser.Connect(controlURL, browser.Options{})
+	if err != nil {
+		return nil, err
 	}
-	defer b.MustClose()
+	defer conn.Close()
 
-	return PageOnBrowser(b, opts)
+	return PageOnBrowser(conn.Browser(), opts)
 }
 
 // PageOnBrowser navigates to the target URL using an existing browser connection
 // and returns the fully rendered HTML. The browser is NOT closed after the fetch,
 // making this suitable for server mode with a persistent browser.
+// Unless opts.Persistent is set, the page is opened in a fresh incognito browser
+// context that is disposed after the fetch, so no state leaks between fetches.
 func PageOnBrowser(b *rod.Browser, opts Options) (*Result, error) {
+	if !opts.Persistent {
+		incognito, err := browser.NewContext(b, opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		defer incognito.Close()
+		b = incognito
+	}
+
 	page, err := b.Page(proto.TargetCreateTarget{URL: "about:blank"})
 	if err != nil {
 		return nil, fmt.Errorf("creating page: %w", err)
 	}
 	defer page.MustClose()
 
-	userAgent := opts.UserAgent
-	if opts.Mobile {
-		// Set mobile viewport: iPhone 14 Pro Max logical resolution.
-		err := proto.EmulationSetDeviceMetricsOverride{
-			Width:             430,
-			Height:            932,
-			DeviceScaleFactor: 3,
-			Mobile:            true,
-		}.Call(page)
-		if err != nil {
-			return nil, fmt.Errorf("setting mobile viewport: %w", err)
-		}
-		if userAgent == "" {
-			userAgent = mobileUserAgent
-		}
+	interceptor, stopIntercept, err := intercept(page, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer stopIntercept()
 
-	if userAgent != "" {
-		err := proto.NetworkSetUserAgentOverride{UserAgent: userAgent}.Call(page)
-		if err != nil {
-			return nil, fmt.Errorf("setting user agent: %w", err)
-		}
+	recorder, stopRecording := recordResponses(page)
+	defer stopRecording()
+
+	if err := emulate(page, opts); err != nil {
+		return nil, err
 	}
 
 	// Apply timeout to navigation and DOM wait, but not HTML extraction.
@@ -116,9 +282,11 @@ func PageOnBrowser(b *rod.Browser, opts Options) (*Result, error) {
 
 	if err := timedPage.Navigate(opts.URL); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return &Result{TimedOut: true}, nil
+			result := &Result{TimedOut: true, Blocked: interceptor.counts(), Response: recorder.response()}
+			result.screenshot(page, opts)
+			return result, nil
 		}
-		return nil, fmt.Errorf("navigating to %s: %w", opts.URL, err)
+		return nil, &NavigationError{URL: opts.URL, Err: fmt.Errorf("navigating to %s: %w", opts.URL, err)}
 	}
 
 	// Wait for the load event first (resources + scripts loaded), then for
@@ -129,7 +297,7 @@ func PageOnBrowser(b *rod.Browser, opts Options) (*Result, error) {
 		if errors.Is(err, context.DeadlineExceeded) {
 			timedOut = true
 		} else {
-			return nil, fmt.Errorf("waiting for page load: %w", err)
+			return nil, &NavigationError{URL: opts.URL, Err: fmt.Errorf("waiting for page load: %w", err)}
 		}
 	}
 
@@ -138,7 +306,7 @@ func PageOnBrowser(b *rod.Browser, opts Options) (*Result, error) {
 			if errors.Is(err, context.DeadlineExceeded) {
 				timedOut = true
 			} else {
-				return nil, fmt.Errorf("waiting for DOM stable: %w", err)
+				return nil, &NavigationError{URL: opts.URL, Err: fmt.Errorf("waiting for DOM stable: %w", err)}
 			}
 		}
 	}
@@ -153,5 +321,15 @@ func PageOnBrowser(b *rod.Browser, opts Options) (*Result, error) {
 		return nil, fmt.Errorf("extracting HTML: %w", err)
 	}
 
-	return &Result{HTML: html, TimedOut: timedOut}, nil
+	result := &Result{HTML: html, TimedOut: timedOut, Blocked: interceptor.counts()}
+	result.screenshot(page, opts)
+
+	// The final URL may differ from the last response after client-side navigation.
+	resp := recorder.response()
+	if info, err := page.Info(); err == nil && info.URL != "" {
+		resp.FinalURL = info.URL
+	}
+
+	result.Response = resp
+	return result, nil
 }
@@ -0,0 +1,33 @@
+package fetch
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDirectBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		size := int64(10)
+		if r.URL.Path == "/large" {
+			size = MaxBodySize + 1
+		}
+		io.CopyN(w, strings.NewReader(strings.Repeat("a", int(size))), size)
+	}))
+	defer srv.Close()
+
+	result, err := Direct(Options{URL: srv.URL + "/small"})
+	if err != nil || result == nil || result.Text != "aaaaaaaaaa" {
+		t.Fatalf("Direct(small) = %+v, %v", result, err)
+	}
+
+	_, err = Direct(Options{URL: srv.URL + "/large"})
+	var tooLarge *TooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("Direct(large) error = %v, want *TooLargeError", err)
+	}
+}
@@ -0,0 +1,128 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// interceptor pauses the page's requests via the Fetch domain to block unwanted
+// resource types and domains, and to answer proxy auth challenges.
+type interceptor struct {
+	page         *rod.Page
+	blockTypes   map[proto.NetworkResourceType]string
+	blockDomains []string
+	proxyUser    *url.Userinfo
+
+	mu      sync.Mutex
+	blocked map[string]int
+}
+
+// intercept starts request interception on page according to opts. It returns a
+// nil interceptor if there is nothing to intercept. Call stop once the page is done.
+func intercept(page *rod.Page, opts Options) (i *interceptor, stop func(), err error) {
+	i = &interceptor{
+		page:         page,
+		blockTypes:   map[proto.NetworkResourceType]string{},
+		blockDomains: opts.BlockDomains,
+		blocked:      map[string]int{},
+	}
+	for _, name := range opts.BlockResources {
+		name = strings.ToLower(name)
+		if t, ok := resourceTypes[name]; ok {
+			i.blockTypes[t] = name
+		}
+	}
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing proxy: %w", err)
+		}
+		i.proxyUser = u.User
+	}
+
+	if len(i.blockTypes) == 0 && len(i.blockDomains) == 0 && i.proxyUser == nil {
+		return nil, func() {}, nil
+	}
+
+	enable := proto.FetchEnable{HandleAuthRequests: i.proxyUser != nil}
+	if err := enable.Call(page); err != nil {
+		return nil, nil, fmt.Errorf("enabling request interception: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go page.Context(ctx).EachEvent(i.onRequestPaused, i.onAuthRequired)()
+
+	return i, cancel, nil
+}
+
+func (i *interceptor) onRequestPaused(e *proto.FetchRequestPaused) {
+	if reason := i.blockReason(e); reason != "" {
+		i.mu.Lock()
+		i.blocked[reason]++
+		i.mu.Unlock()
+		_ = proto.FetchFailRequest{
+			RequestID:   e.RequestID,
+			ErrorReason: proto.NetworkErrorReasonBlockedByClient,
+		}.Call(i.page)
+		return
+	}
+	_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(i.page)
+}
+
+// blockReason returns the counter a request should be blocked under, or "" to let it through.
+// The main frame's document is never blocked.
+func (i *interceptor) blockReason(e *proto.FetchRequestPaused) string {
+	if e.ResourceType == proto.NetworkResourceTypeDocument && e.FrameID == i.page.FrameID {
+		return ""
+	}
+	if name, ok := i.blockTypes[e.ResourceType]; ok {
+		return name
+	}
+	if len(i.blockDomains) > 0 {
+		if u, err := url.Parse(e.Request.URL); err == nil && domainBlocked(u.Hostname(), i.blockDomains) {
+			return "domain"
+		}
+	}
+	return ""
+}
+
+// onAuthRequired answers proxy auth challenges with the proxy's credentials.
+// Other challenges get Chrome's default handling.
+func (i *interceptor) onAuthRequired(e *proto.FetchAuthRequired) {
+	resp := &proto.FetchAuthChallengeResponse{
+		Response: proto.FetchAuthChallengeResponseResponseDefault,
+	}
+	if i.proxyUser != nil && e.AuthChallenge.Source == proto.FetchAuthChallengeSourceProxy {
+		password, _ := i.proxyUser.Password()
+		resp = &proto.FetchAuthChallengeResponse{
+			Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+			Username: i.proxyUser.Username(),
+			Password: password,
+		}
+	}
+	_ = proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: resp}.Call(i.page)
+}
+
+// counts returns the number of blocked requests per reason (resource type or "domain").
+func (i *interceptor) counts() map[string]int {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if len(i.blocked) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(i.blocked))
+	for k, v := range i.blocked {
+		counts[k] = v
+	}
+	return counts
+}
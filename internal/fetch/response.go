@@ -0,0 +1,123 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Redirect is one hop of a redirect chain.
+type Redirect struct {
+	URL    string // URL that responded with the redirect
+	Status int
+}
+
+// Response describes the main document response for a fetched URL.
+type Response struct {
+	FinalURL  string            // URL after redirects (and client-side navigation, for browser fetches)
+	Status    int               // HTTP status of the final response; 0 if none was received
+	Headers   map[string]string // Response headers of the final response
+	Redirects []Redirect        // Redirect hops in order, excluding the final response
+}
+
+// StatusError reports an upstream response whose status matched a StatusMatcher.
+type StatusError struct {
+	URL    string
+	Status int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned HTTP %d", e.URL, e.Status)
+}
+
+// StatusMatcher matches HTTP status codes against patterns such as "404" or "5xx".
+type StatusMatcher []string
+
+// ParseStatusMatcher validates status patterns: exact codes ("404") or classes ("4xx").
+func ParseStatusMatcher(patterns []string) (StatusMatcher, error) {
+	var m StatusMatcher
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		valid := len(p) == 3 && p[0] >= '1' && p[0] <= '5'
+		if valid && p[1:] != "xx" {
+			_, err := strconv.Atoi(p)
+			valid = err == nil
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid status pattern %q (want e.g. 404 or 5xx)", p)
+		}
+		m = append(m, p)
+	}
+	return m, nil
+}
+
+// Match reports whether status matches any pattern.
+func (m StatusMatcher) Match(status int) bool {
+	s := strconv.Itoa(status)
+	for _, p := range m {
+		if p == s || (strings.HasSuffix(p, "xx") && len(s) == 3 && p[0] == s[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder tracks the main frame's document responses via network events.
+type responseRecorder struct {
+	page *rod.Page
+
+	mu   sync.Mutex
+	resp Response
+}
+
+// recordResponses starts recording page's main frame responses. Call stop once
+// navigation is done.
+func recordResponses(page *rod.Page) (r *responseRecorder, stop func()) {
+	r = &responseRecorder{page: page}
+	ctx, cancel := context.WithCancel(context.Background())
+	go page.Context(ctx).EachEvent(r.onRequestWillBeSent, r.onResponseReceived)()
+	return r, cancel
+}
+
+func (r *responseRecorder) mainDocument(t proto.NetworkResourceType, frame proto.PageFrameID) bool {
+	return t == proto.NetworkResourceTypeDocument && frame == r.page.FrameID
+}
+
+func (r *responseRecorder) onRequestWillBeSent(e *proto.NetworkRequestWillBeSent) {
+	if !r.mainDocument(e.Type, e.FrameID) || e.RedirectResponse == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resp.Redirects = append(r.resp.Redirects, Redirect{URL: e.RedirectResponse.URL, Status: e.RedirectResponse.Status})
+}
+
+func (r *responseRecorder) onResponseReceived(e *proto.NetworkResponseReceived) {
+	if !r.mainDocument(e.Type, e.FrameID) {
+		return
+	}
+	headers := make(map[string]string, len(e.Response.Headers))
+	for k, v := range e.Response.Headers {
+		headers[strings.ToLower(k)] = v.Str()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resp.FinalURL = e.Response.URL
+	r.resp.Status = e.Response.Status
+	r.resp.Headers = headers
+}
+
+// response returns what has been recorded so far.
+func (r *responseRecorder) response() Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resp
+}
@@ -0,0 +1,37 @@
+package fetch
+
+import "testing"
+
+func TestStatusMatcher(t *testing.T) {
+	m, err := ParseStatusMatcher([]string{"4xx", " 503 ", ""})
+	if err != nil {
+		t.Fatalf("ParseStatusMatcher() error: %v", err)
+	}
+
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{200, false},
+		{301, false},
+		{400, true},
+		{404, true},
+		{499, true},
+		{500, false},
+		{503, true},
+		{0, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.status); got != tt.want {
+			t.Errorf("Match(%d) = %t, want %t", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseStatusMatcherInvalid(t *testing.T) {
+	for _, p := range []string{"4x", "600", "abc", "40x", "xx"} {
+		if _, err := ParseStatusMatcher([]string{p}); err == nil {
+			t.Errorf("ParseStatusMatcher(%q) should fail", p)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package fetch
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Screenshot formats.
+const (
+	ScreenshotPNG  = "png"
+	ScreenshotJPEG = "jpeg"
+)
+
+// Screenshot asks for an image of the rendered page, captured after the page
+// has settled and its HTML has been extracted.
+type Screenshot struct {
+	FullPage bool   // Capture the whole scrollable page instead of the viewport
+	Format   string // ScreenshotPNG or ScreenshotJPEG
+	Quality  int    // JPEG quality from 1 to 100; 0 uses Chrome's default
+}
+
+// Validate checks the screenshot format and quality.
+func (s *Screenshot) Validate() error {
+	switch s.Format {
+	case ScreenshotPNG, ScreenshotJPEG:
+	default:
+		return fmt.Errorf("invalid screenshot format %q (want png or jpeg)", s.Format)
+	}
+	if s.Quality < 0 || s.Quality > 100 {
+		return fmt.Errorf("invalid screenshot quality %d (want 1 to 100)", s.Quality)
+	}
+	return nil
+}
+
+// ScreenshotFormat returns the screenshot format for a file name by its
+// extension: .png, or .jpg or .jpeg.
+func ScreenshotFormat(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png":
+		return ScreenshotPNG, nil
+	case ".jpg", ".jpeg":
+		return ScreenshotJPEG, nil
+	}
+	return "", fmt.Errorf("invalid screenshot file %q (want a .png, .jpg, or .jpeg extension)", name)
+}
+
+// defaultScreenshotTimeout bounds the capture when the page has no timeout.
+const defaultScreenshotTimeout = 30 * time.Second
+
+// screenshot captures the screenshot opts ask for, if any, into r. A failed
+// capture is kept in ScreenshotErr rather than failing the page.
+func (r *Result) screenshot(page *rod.Page, opts Options) {
+	if opts.Screenshot == nil {
+		return
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultScreenshotTimeout
+	}
+	// A fresh timeout: the page's own may have run out already.
+	timed := page.Timeout(timeout)
+	defer timed.CancelTimeout()
+	r.Screenshot, r.ScreenshotErr = capture(timed, opts.Screenshot)
+}
+
+// capture takes the screenshot described by s.
+func capture(page *rod.Page, s *Screenshot) ([]byte, error) {
+	req := &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng}
+	if s.Format == ScreenshotJPEG {
+		req.Format = proto.PageCaptureScreenshotFormatJpeg
+		if s.Quality > 0 {
+			quality := s.Quality
+			req.Quality = &quality
+		}
+	}
+	data, err := page.Screenshot(s.FullPage, req)
+	if err != nil {
+		return nil, fmt.Errorf("capturing screenshot: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,46 @@
+package fetch
+
+import "testing"
+
+func TestScreenshotFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "out.png", want: ScreenshotPNG},
+		{name: "shots/Page.JPG", want: ScreenshotJPEG},
+		{name: "page.jpeg", want: ScreenshotJPEG},
+		{name: "page.webp", wantErr: true},
+		{name: "page", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScreenshotFormat(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScreenshotFormat(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ScreenshotFormat(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScreenshotValidate(t *testing.T) {
+	tests := []struct {
+		shot    Screenshot
+		wantErr bool
+	}{
+		{shot: Screenshot{Format: ScreenshotPNG}},
+		{shot: Screenshot{Format: ScreenshotJPEG, Quality: 100, FullPage: true}},
+		{shot: Screenshot{Format: "gif"}, wantErr: true},
+		{shot: Screenshot{Format: ScreenshotJPEG, Quality: 101}, wantErr: true},
+		{shot: Screenshot{Format: ScreenshotJPEG, Quality: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.shot.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, wantErr %v", tt.shot, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package mdblock splits markdown into blocks and sentences without parsing
+// it, for code that cuts markdown at natural boundaries (token truncation,
+// chunking) and must agree on where those are. It also writes code fences that
+// the content inside can't close.
+package mdblock
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Block is the byte range of a markdown block: a run of lines between blank
+// lines. Fenced code blocks are kept whole, blank lines included.
+type Block struct {
+	Start, End int
+	Fenced     bool // The block contains a fenced code block
+}
+
+// Split splits md into blocks, in order.
+func Split(md string) []Block {
+	var out []Block
+	start, end := -1, 0
+	fence := ""
+	fenced := false
+	for pos := 0; pos < len(md); {
+		lineEnd := strings.IndexByte(md[pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(md)
+		} else {
+			lineEnd += pos
+		}
+		line := md[pos:lineEnd]
+		trimmed := strings.TrimLeft(line, " ")
+		blank := false
+		switch {
+		case fence != "":
+			if Closes(trimmed, fence) {
+				fence = ""
+			}
+		case Fence(trimmed) != "":
+			fence, fenced = Fence(trimmed), true
+		case strings.TrimSpace(line) == "":
+			blank = true
+		}
+		if blank {
+			if start >= 0 {
+				out = append(out, Block{start, end, fenced})
+				start, fenced = -1, false
+			}
+		} else {
+			if start < 0 {
+				start = pos
+			}
+			end = lineEnd
+		}
+		pos = lineEnd + 1
+	}
+	if start >= 0 {
+		out = append(out, Block{start, end, fenced})
+	}
+	return out
+}
+
+// Fence returns the code fence (``` or ~~~, or longer) that starts a line with
+// its indentation trimmed, or "" if there is none.
+func Fence(line string) string {
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, f) {
+			return line[:len(line)-len(strings.TrimLeft(line, f[:1]))]
+		}
+	}
+	return ""
+}
+
+// CodeBlock fences s as a code block with the info string lang, using a fence
+// longer than any run of backticks inside it.
+func CodeBlock(s, lang string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(s, "\n") + "\n" + fence + "\n"
+}
+
+// Closes reports whether a line with its indentation trimmed closes the code
+// block opened by fence: a fence at least as long, with nothing after it.
+func Closes(line, fence string) bool {
+	return strings.HasPrefix(line, fence) && strings.TrimRight(strings.TrimLeft(line, fence[:1]), " \t") == ""
+}
+
+// SentenceEnd matches the end of a sentence: terminal punctuation, any closing
+// quotes, brackets, or emphasis, and the whitespace after them.
+var SentenceEnd = regexp.MustCompile(`[.!?]["')\]*_]*\s+`)
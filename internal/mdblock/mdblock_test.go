@@ -0,0 +1,62 @@
+package mdblock
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	md := "# Title\n\na\nb\n\n````go\nx\n\n```\ny\n````\n\n~~~\nz\n~~~ \n\nc"
+	want := []string{"# Title", "a\nb", "````go\nx\n\n```\ny\n````", "~~~\nz\n~~~ ", "c"}
+	got := Split(md)
+	if len(got) != len(want) {
+		t.Fatalf("Split() = %+v, want %d blocks", got, len(want))
+	}
+	for i, b := range got {
+		if text := md[b.Start:b.End]; text != want[i] {
+			t.Errorf("block %d = %q, want %q", i, text, want[i])
+		}
+		if fenced := i == 2 || i == 3; b.Fenced != fenced {
+			t.Errorf("block %d Fenced = %v, want %v", i, b.Fenced, fenced)
+		}
+	}
+}
+
+func TestFence(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"```", "```"},
+		{"````go", "````"},
+		{"~~~ yaml", "~~~"},
+		{"``", ""},
+		{"text ```", ""},
+	}
+	for _, tt := range tests {
+		if got := Fence(tt.line); got != tt.want {
+			t.Errorf("Fence(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+	if Closes("```go", "```") || Closes("```", "````") || !Closes("`````  ", "````") {
+		t.Error("Closes() accepted an info string or a shorter fence, or rejected a longer one")
+	}
+}
+
+func TestCodeBlock(t *testing.T) {
+	if got, want := CodeBlock("x := 1\n", "go"), "```go\nx := 1\n```\n"; got != want {
+		t.Errorf("CodeBlock() = %q, want %q", got, want)
+	}
+	if got, want := CodeBlock("a ``` b ```` c", "json"), "`````json\na ``` b ```` c\n`````\n"; got != want {
+		t.Errorf("CodeBlock() = %q, want %q", got, want)
+	}
+}
+
+func TestSentenceEnd(t *testing.T) {
+	got := SentenceEnd.FindAllString(`One. "Two?" (Three!) *Four.* Five`, -1)
+	want := []string{". ", `?" `, "!) ", ".* "}
+	if len(got) != len(want) {
+		t.Fatalf("SentenceEnd matched %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("match %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package tokens counts tokens in markdown and truncates it to a token budget.
+package tokens
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/boozedog/webmd/internal/mdblock"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Estimate is the name of the character-based estimator.
+const Estimate = "estimate"
+
+// DefaultEncoding is the tokenizer used unless another is requested.
+const DefaultEncoding = string(tokenizer.O200kBase)
+
+// encodings are the accepted tokenizer names besides Estimate.
+var encodings = []tokenizer.Encoding{tokenizer.O200kBase, tokenizer.Cl100kBase, tokenizer.P50kBase, tokenizer.R50kBase}
+
+// Counter counts tokens in text.
+type Counter interface {
+	Name() string
+	Count(s string) int
+}
+
+var (
+	mu     sync.Mutex
+	codecs = map[string]tokenizer.Codec{}
+)
+
+// New returns a counter for a BPE encoding such as o200k_base or cl100k_base,
+// or for Estimate, which assumes four characters per token. Loaded encodings
+// are cached and shared.
+func New(name string) (Counter, error) {
+	if name == Estimate {
+		return estimator{}, nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if c, ok := codecs[name]; ok {
+		return bpe{c}, nil
+	}
+	for _, enc := range encodings {
+		if string(enc) == name {
+			c, err := tokenizer.Get(enc)
+			if err != nil {
+				return nil, fmt.Errorf("loading tokenizer %s: %w", name, err)
+			}
+			codecs[name] = c
+			return bpe{c}, nil
+		}
+	}
+	names := []string{Estimate}
+	for _, enc := range encodings {
+		names = append(names, string(enc))
+	}
+	return nil, fmt.Errorf("unknown tokenizer %q (want one of: %s)", name, strings.Join(names, ", "))
+}
+
+type bpe struct {
+	codec tokenizer.Codec
+}
+
+func (b bpe) Name() string { return b.codec.GetName() }
+
+func (b bpe) Count(s string) int {
+	n, err := b.codec.Count(s)
+	if err != nil {
+		return estimator{}.Count(s)
+	}
+	return n
+}
+
+type estimator struct{}
+
+func (estimator) Name() string { return Estimate }
+
+func (estimator) Count(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// Truncate shortens md to at most max tokens as counted by c. It cuts between
+// blocks (headings, paragraphs, lists, code blocks), falling back to sentence
+// boundaries within a paragraph, and never leaves a heading without content.
+// An explicit marker noting the truncation is appended. It reports whether md
+// was truncated.
+func Truncate(md string, max int, c Counter) (string, bool) {
+	total := c.Count(md)
+	if total <= max {
+		return md, false
+	}
+
+	marker := fmt.Sprintf("[webmd: truncated to %d of %d tokens]", max, total)
+	budget := max - c.Count(marker) - 1
+
+	var kept []string
+	for _, b := range mdblock.Split(md) {
+		block := md[b.Start:b.End]
+		n := c.Count(block) + 1 // +1 for the blank line between blocks
+		if n <= budget {
+			kept = append(kept, block)
+			budget -= n
+			continue
+		}
+		if !isHeading(block) && !b.Fenced {
+			if partial := sentences(block, budget, c); partial != "" {
+				kept = append(kept, partial)
+			}
+		}
+		break
+	}
+	for len(kept) > 0 && isHeading(kept[len(kept)-1]) {
+		kept = kept[:len(kept)-1]
+	}
+
+	if len(kept) == 0 {
+		return marker + "\n", true
+	}
+	return strings.Join(kept, "\n\n") + "\n\n" + marker + "\n", true
+}
+
+func isHeading(block string) bool {
+	return strings.HasPrefix(block, "#") && !strings.Contains(block, "\n")
+}
+
+// sentences returns the longest run of whole sentences from the start of
+// block that fits in budget tokens, or "" if not even one does.
+func sentences(block string, budget int, c Counter) string {
+	best := ""
+	for _, loc := range mdblock.SentenceEnd.FindAllStringIndex(block, -1) {
+		candidate := strings.TrimSpace(block[:loc[1]])
+		if c.Count(candidate) > budget {
+			break
+		}
+		best = candidate
+	}
+	return best
+}
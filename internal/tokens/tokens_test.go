@@ -0,0 +1,58 @@
+package tokens
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	c, err := New(DefaultEncoding)
+	if err != nil {
+		t.Fatalf("New(%s) error: %v", DefaultEncoding, err)
+	}
+	if c.Name() != "o200k_base" {
+		t.Errorf("Name() = %q", c.Name())
+	}
+	if n := c.Count("hello world"); n != 2 {
+		t.Errorf("Count(hello world) = %d, want 2", n)
+	}
+
+	e, err := New(Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := e.Count("abcdefghi"); n != 3 {
+		t.Errorf("estimate Count = %d, want 3", n)
+	}
+
+	if _, err := New("gpt-9"); err == nil {
+		t.Error("New(gpt-9) should fail")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	c := estimator{}
+	md := "# Title\n\n" +
+		"First paragraph is here.\n\n" +
+		"## Section\n\n" +
+		"Second paragraph. It has two sentences.\n\n" +
+		"```\ncode block that is long enough to matter\n```\n"
+
+	if got, truncated := Truncate(md, 1000, c); truncated || got != md {
+		t.Errorf("Truncate under budget changed md: %q", got)
+	}
+
+	tests := []struct {
+		max  int
+		want string
+	}{
+		// Drops the trailing "## Section" heading rather than leaving it empty.
+		{25, "# Title\n\nFirst paragraph is here.\n\n[webmd: truncated to 25 of 35 tokens]\n"},
+		// Cuts the second paragraph at a sentence boundary.
+		{33, "# Title\n\nFirst paragraph is here.\n\n## Section\n\nSecond paragraph.\n\n[webmd: truncated to 33 of 35 tokens]\n"},
+		{3, "[webmd: truncated to 3 of 35 tokens]\n"},
+	}
+	for _, tt := range tests {
+		got, truncated := Truncate(md, tt.max, c)
+		if !truncated || got != tt.want {
+			t.Errorf("Truncate(max=%d) =\n%q\nwant:\n%q", tt.max, got, tt.want)
+		}
+	}
+}